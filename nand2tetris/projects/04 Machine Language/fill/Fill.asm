@@ -17,7 +17,7 @@
     D=A
     @size
     M=D
-(INITIALISE-BLACKING)
+(INITIALISE_BLACKING)
 // i = 0 
     @i
     M=0
@@ -25,7 +25,7 @@
     // KBD != 0
     @KBD
     D=M
-    @INITIALISE-CLEANING
+    @INITIALISE_CLEANING
     D;JEQ
     // okay KBD != 0 true 
     // i != size 
@@ -45,14 +45,14 @@
     M=M+1
     @BLACKING
     0;JMP
-(INITIALISE-CLEANING)
+(INITIALISE_CLEANING)
     // i = 0 
     @i
     M=0
 (CLEANING)
     @KBD
     D=M
-    @INITIALISE-BLACKING
+    @INITIALISE_BLACKING
     D;JNE
     // KBD == 0 is true 
     @i
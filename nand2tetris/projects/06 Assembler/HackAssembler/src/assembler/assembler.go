@@ -0,0 +1,277 @@
+package assembler
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/ishwar00/HackAssembler/errHandler"
+)
+
+// Program is the machine code produced by Assemble,
+// Words[i] is the instruction stored at ROM address i and
+// Instrs[i] is the source instruction it was assembled from.
+type Program struct {
+	Name    string
+	Words   []uint16
+	Instrs  []InstructionInfo
+	Source  map[string][]string // key: fileName, value: lines of file as read
+	Symbols SymbolTable
+	Removed []InstructionInfo // instructions removed by optimizer, see Parser.Optimize
+
+	// warnings found in program, nil if there are none, see lint
+	Warnings *errhandler.ErrHandler
+}
+
+// Options changes how Assemble processes the source.
+type Options struct {
+	// opens files named by .include directive, if nil, they are opened from disk
+	Include func(path string) (io.ReadCloser, error)
+
+	// accept only the documented comp and dest mnemonics, as the course does.
+	// otherwise commuted spellings, extended ALU functions and raw comp bits
+	// are accepted too, see Code.ExtendedComp and Code.ExtendedDest
+	Strict bool
+
+	// remove instructions which do not change the behaviour of program,
+	// see Parser.Optimize. Program is checked for errors before optimizing,
+	// so errors in removed instructions are still reported. Variables keep
+	// the RAM addresses they have without optimizing.
+	Optimize bool
+
+	// treat warnings as errors, program with warnings fails to assemble
+	Werror bool
+
+	// machine program is assembled for, if nil, it is DefaultTarget
+	Target *Target
+}
+
+// Assemble translates Hack assembly read from src into machine code.
+// name is used to identify the source in error messages and to
+// resolve .include paths relative to it.
+// Assembly does not stop at the first faulty instruction, if there are
+// any errors the returned error is *errhandler.ErrHandler holding all of them.
+func Assemble(src io.Reader, name string) (Program, error) {
+	return AssembleWith(src, name, Options{})
+}
+
+// same as Assemble, but behaviour is adjusted by opts.
+func AssembleWith(src io.Reader, name string, opts Options) (Program, error) {
+	var errs errhandler.ErrHandler
+	preprocessor, parser, err := parseSource(src, name, opts, &errs)
+	if err != nil {
+		return Program{}, err
+	}
+
+	program := assembleChecked(preprocessor, parser, opts, &errs)
+	if errs.Error_count() > 0 {
+		return Program{}, &errs
+	}
+	if opts.Optimize {
+		removed := parser.Optimize()
+		program = assembleParsedWith(parser, preprocessor.Constants, opts, &errs, &program.Symbols)
+		if errs.Error_count() > 0 {
+			return Program{}, &errs
+		}
+		program.Removed = removed
+	}
+	program.Name = name
+	program.Source = preprocessor.GetSources()
+	if errs.Warning_count() > 0 {
+		program.Warnings = &errs
+	}
+	return program, nil
+}
+
+// Analyze assembles src as AssembleWith does, but returns the program even if
+// it has errors, for tools like language server, which look into a program
+// while it is being edited. Errors and warnings are returned in diags, faulty
+// instructions are left out of Program.Words and Program.Instrs, so their
+// index is not ROM address then. err is only returned if src can not be read.
+// opts.Optimize is ignored.
+func Analyze(src io.Reader, name string, opts Options) (program Program, diags []errhandler.Error, err error) {
+	var errs errhandler.ErrHandler
+	preprocessor, parser, err := parseSource(src, name, opts, &errs)
+	if err != nil {
+		return Program{}, nil, err
+	}
+
+	program = assembleChecked(preprocessor, parser, opts, &errs)
+	program.Name = name
+	program.Source = preprocessor.GetSources()
+	return program, errs.Errors(), nil
+}
+
+// preprocesses src and initializes parser with its lines, sources are added
+// to errs to show lines of errors. error is only for failure to read src.
+func parseSource(src io.Reader, name string, opts Options, errs *errhandler.ErrHandler) (Preprocessor, Parser, error) {
+	var preprocessor Preprocessor
+	var parser Parser
+	preprocessor.Initialize(opts.Include, errs)
+	lines, err := preprocessor.Process(src, name)
+	if err != nil {
+		return Preprocessor{}, Parser{}, fmt.Errorf("%v: %w", name, err)
+	}
+	for fileName, source := range preprocessor.GetSources() {
+		errs.AddSource(fileName, source)
+	}
+	parser.Initialize(lines)
+	return preprocessor, parser, nil
+}
+
+// assembles parsed program and checks its .export and .import declarations,
+// program is linted only if it has no errors.
+func assembleChecked(preprocessor Preprocessor, parser Parser, opts Options, errs *errhandler.ErrHandler) Program {
+	program := assembleParsed(parser, preprocessor.Constants, opts, errs)
+	checkDeclarations(preprocessor.Exports, program.Symbols, errs, exportNotDeclared)
+	checkDeclarations(preprocessor.Imports, program.Symbols, errs, importNotDeclared)
+	if errs.Error_count() == 0 {
+		lint(parser, program.Symbols, opts, errs)
+	}
+	return program
+}
+
+// resolves symbols and translates parsed instructions into machine code,
+// errors are added to errs.
+func assembleParsed(parser Parser, constants []Constant, opts Options, errs *errhandler.ErrHandler) Program {
+	return assembleParsedWith(parser, constants, opts, errs, nil)
+}
+
+// same as assembleParsed, but variables found in variables, if not nil, keep
+// their RAM addresses there, instead of being allocated in order of first use.
+func assembleParsedWith(parser Parser, constants []Constant, opts Options, errs *errhandler.ErrHandler, variables *SymbolTable) Program {
+	var code Code
+	var st SymbolTable
+	target := opts.target()
+	code.Initialize()
+	st.InitializeWith(target)
+	program := Program{
+		Words:  []uint16{},
+		Instrs: []InstructionInfo{},
+	}
+
+	addConstants(&st, constants, errs)
+	st.DoPass1(parser, errs)
+
+	for parser.HasMoreLines() {
+		parser.Advance()
+		switch parser.InstructionType() {
+		case L_INSTRUCTION:
+			// Lable declaration (xxx) produces no code
+			continue
+
+		case A_INSTRUCTION:
+			operand := parser.Symbol()
+			site := parser.GetInstrInfo().Site()
+			resolve := func(symbol string) int {
+				if !st.Contains(symbol) && variables != nil && variables.Kind(symbol) == VARIABLE_SYMBOL {
+					st.AddEntry(symbol, variables.GetAddress(symbol), VARIABLE_SYMBOL)
+					st.SetDefinedAt(symbol, site)
+				} else if !st.Contains(symbol) {
+					if err := st.AddVariable(symbol, site); err != nil {
+						errs.Add(parser.ErrorAt(1+strings.Index(operand, symbol), len(symbol), err.Error()))
+					}
+				}
+				st.MarkUse(symbol, site)
+				return st.GetAddress(symbol)
+			}
+
+			value, err := evaluateOperand(operand, resolve)
+			if err != nil {
+				e := err.(*operandError)
+				errs.Add(parser.ErrorAt(1+e.offset, e.length, e.errMsg))
+				continue
+			}
+			program.Words = append(program.Words, uint16(value))
+			program.Instrs = append(program.Instrs, parser.GetInstrInfo())
+
+		case C_INSTRUCTION:
+			word, cErrs := encodeC(code, parser.GetInstrInfo().Instr, opts)
+			for _, e := range cErrs {
+				errs.Add(parser.ErrorAt(e.offset, e.length, e.errMsg))
+			}
+			if len(cErrs) > 0 {
+				continue
+			}
+			program.Words = append(program.Words, word)
+			program.Instrs = append(program.Instrs, parser.GetInstrInfo())
+		default:
+			instr := parser.GetInstrInfo().Instr
+			errs.Add(parser.ErrorAt(0, len(instr), "alien instruction: failed to classify the instruction"))
+		}
+	}
+
+	if len(program.Words) > target.ROMSize {
+		errs.Add(romOverflow(program.Instrs[target.ROMSize], len(program.Words), target))
+	}
+	program.Symbols = st
+	return program
+}
+
+// adds constants defined with .equ to st, constant may not redefine
+// a predefined symbol.
+func addConstants(st *SymbolTable, constants []Constant, errs *errhandler.ErrHandler) {
+	for _, constant := range constants {
+		if st.Contains(constant.Name) {
+			errMsg := fmt.Sprintf("constant %v is a predefined symbol", color.RedString(constant.Name))
+			errs.Add(errhandler.Error{
+				ErrMsg:   errMsg,
+				OnLine:   constant.Line.AtLine - 1,
+				OnColumn: strings.Index(constant.Line.Text, constant.Name),
+				Length:   len(constant.Name),
+				File:     constant.Line.File,
+			})
+			continue
+		}
+		st.AddEntry(constant.Name, constant.Value, CONSTANT_SYMBOL)
+		st.SetDefinedAt(constant.Name, SymbolSite{File: constant.Line.File, Line: constant.Line.AtLine})
+	}
+}
+
+// error at first instruction which does not fit in ROM of target.
+func romOverflow(instrInfo InstructionInfo, size int, target Target) errhandler.Error {
+	errMsg := fmt.Sprintf("program has %v instructions, but ROM holds %d, this is the first one beyond ROM",
+		color.RedString(strconv.Itoa(size)), target.ROMSize)
+	return errorAt(instrInfo, 0, len(instrInfo.Instr), errMsg)
+}
+
+// encodes C instruction dest=comp;jump, every unknown field is returned
+// as an error, its offset and length locate the field in instr.
+func encodeC(code Code, instr string, opts Options) (uint16, []operandError) {
+	dest, comp, jump := splitC(instr)
+	destBits, compBits, jumpBits := code.Dest(dest), code.Comp(comp), code.Jump(jump)
+	if !opts.Strict {
+		destBits, compBits = code.ExtendedDest(dest), code.ExtendedComp(comp)
+	}
+
+	errs := []operandError{}
+	if destBits == "" {
+		errMsg := fmt.Sprintf("unknown dest %v%v", color.RedString(dest), strictHint(opts, code.ExtendedDest(dest)))
+		errs = append(errs, operandError{0, len(dest), errMsg})
+	}
+	if compBits == "" {
+		errMsg := fmt.Sprintf("unknown comp %v%v", color.RedString(comp), strictHint(opts, code.ExtendedComp(comp)))
+		errs = append(errs, operandError{strings.Index(instr, "=") + 1, len(comp), errMsg})
+	}
+	if jumpBits == "" {
+		errMsg := fmt.Sprintf("unknown jump %v", color.RedString(jump))
+		errs = append(errs, operandError{strings.Index(instr, ";") + 1, len(jump), errMsg})
+	}
+	if len(errs) > 0 {
+		return 0, errs
+	}
+
+	binaryCode := "111" + compBits + destBits + jumpBits
+	word, _ := strconv.ParseUint(binaryCode, 2, 16)
+	return uint16(word), nil
+}
+
+// explains why mnemonic is rejected, if it is valid only outside of strict mode.
+func strictHint(opts Options, extendedBits string) string {
+	if opts.Strict && extendedBits != "" {
+		return ", it is not a documented mnemonic and is not allowed in strict mode"
+	}
+	return ""
+}
@@ -0,0 +1,289 @@
+package assembler
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ishwar00/HackAssembler/errHandler"
+)
+
+func TestAssembleString(t *testing.T) {
+	input := `
+// computes R0 = 2 + 3
+@2
+D=A
+@3
+D=D+A
+@0
+M=D
+(END)
+    @END   // loop forever
+    0;JMP
+`
+	expected := []uint16{
+		0b0000000000000010,
+		0b1110110000010000,
+		0b0000000000000011,
+		0b1110000010010000,
+		0b0000000000000000,
+		0b1110001100001000,
+		0b0000000000000110,
+		0b1110101010000111,
+	}
+
+	program, err := Assemble(strings.NewReader(input), "test.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if program.Name != "test.asm" {
+		t.Fatalf("expected Name=test.asm, but got=%v", program.Name)
+	}
+
+	if len(program.Words) != len(expected) {
+		t.Fatalf("expected %d words, but got=%d", len(expected), len(program.Words))
+	}
+
+	for i, word := range expected {
+		if program.Words[i] != word {
+			t.Fatalf("Words[%d]: expected=%016b, but got=%016b", i, word, program.Words[i])
+		}
+	}
+}
+
+func TestAssembleVariables(t *testing.T) {
+	input := "@i\n@j\n@i\n@LOOP\n(LOOP)\n@SCREEN\n@R15\n"
+	expected := []uint16{16, 17, 16, 4, 16384, 15}
+
+	program, err := Assemble(strings.NewReader(input), "test.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, word := range expected {
+		if program.Words[i] != word {
+			t.Fatalf("Words[%d]: expected=%v, but got=%v", i, word, program.Words[i])
+		}
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedCount int
+		expectedOut   []string
+	}{
+		{"D=X", 1, []string{"test.asm:1:3: ", "unknown comp"}},
+		{"@12abc", 1, []string{"test.asm:1:2: ", "could not parse"}},
+		{"(LOOP)\n(LOOP)", 1, []string{"test.asm:2:2: ", "duplicate label"}},
+		{"(SP)", 1, []string{"test.asm:1:2: ", "label SP conflicts with predefined symbol"}},
+		{".equ N 3\n(N)", 1, []string{"test.asm:2:2: ", "label N conflicts with constant"}},
+		{"  what", 1, []string{"test.asm:1:3: ", "alien instruction"}},
+		{"(LOOP", 1, []string{"test.asm:1:1: ", "malformed label"}},
+		{"AM=M+1;JMQ", 1, []string{"test.asm:1:8: ", "unknown jump"}},
+		{"DD=0;JMP", 1, []string{"test.asm:1:1: ", "unknown dest"}},
+		{"@x\nD=Q\n@1y\n(x)\nfoo\n(x)\n0;JMP", 4, []string{
+			"test.asm:2:3: ", "test.asm:3:2: ", "test.asm:5:1: ", "test.asm:6:2: ",
+		}},
+	}
+
+	for i, tt := range tests {
+		_, err := Assemble(strings.NewReader(tt.input), "test.asm")
+		if err == nil {
+			t.Fatalf("tests[%d]: expected an error for %q", i, tt.input)
+		}
+
+		errs, ok := err.(*errhandler.ErrHandler)
+		if !ok {
+			t.Fatalf("tests[%d]: expected *errhandler.ErrHandler, but got=%T", i, err)
+		}
+
+		if errs.Error_count() != tt.expectedCount {
+			t.Fatalf("tests[%d]: expected %d errors, but got=%d\n%v",
+				i, tt.expectedCount, errs.Error_count(), err)
+		}
+
+		for _, out := range tt.expectedOut {
+			if !strings.Contains(err.Error(), out) {
+				t.Fatalf("tests[%d]: expected report to contain %q, but got=%v", i, out, err)
+			}
+		}
+	}
+}
+
+func TestAssembleFiles(t *testing.T) {
+	tests := []string{
+		"../../../add/Add.asm",
+		"../../../max/Max.asm",
+		"../../../max/MaxL.asm",
+		"../../../rect/Rect.asm",
+		"../../../rect/RectL.asm",
+		"../../../pong/Pong.asm",
+		"../../../pong/PongL.asm",
+	}
+
+	for _, asmPath := range tests {
+		source, err := os.Open(asmPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		program, err := Assemble(source, asmPath)
+		source.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got bytes.Buffer
+		for _, word := range program.Words {
+			fmt.Fprintf(&got, "%016b\n", word)
+		}
+
+		hackPath := strings.TrimSuffix(asmPath, filepath.Ext(asmPath)) + ".hack"
+		expected, err := os.ReadFile(hackPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected = bytes.ReplaceAll(expected, []byte("\r\n"), []byte("\n"))
+		if !bytes.Equal(bytes.TrimSpace(got.Bytes()), bytes.TrimSpace(expected)) {
+			t.Fatalf("%v: output does not match %v", asmPath, hackPath)
+		}
+	}
+}
+
+func TestWriteListing(t *testing.T) {
+	input := "@i // counter\nM=0\n(LOOP)\n@LOOP\n0;JMP\n(END)\n"
+	expected := []string{
+		"    0  0000000000010000  0010      1  @i // counter",
+		"    1  1110101010001000  EA88      2  M=0",
+		"    2                                 (LOOP)",
+		"    2  0000000000000010  0002      4  @LOOP",
+		"    3  1110101010000111  EA87      5  0;JMP",
+		"    4                                 (END)",
+		"    2  LOOP",
+		"    4  END",
+		"   16  i",
+	}
+
+	program, err := Assemble(strings.NewReader(input), "test.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var listing bytes.Buffer
+	if err := WriteListing(&listing, program); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(listing.String(), "\n")
+	at := 0
+	for _, line := range lines {
+		if at < len(expected) && line == expected[at] {
+			at++
+		}
+	}
+	if at != len(expected) {
+		t.Fatalf("expected line %q in listing, but got=\n%v", expected[at], listing.String())
+	}
+}
+
+func TestExtendedComp(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected uint16
+	}{
+		{"D=A+D", 0b1110000010010000},
+		{"M=M&D", 0b1111000000001000},
+		{"A=M|D", 0b1111010101100000},
+		{"D=1+M", 0b1111110111010000},
+		{"DM=D&!A", 0b1110000100011000},
+		{"D=!(D|M)", 0b1111010100010000},
+		{"-2;JMP", 0b1110111110000111},
+		{"AM=%B0000001", 0b1110000001101000},
+		{"!(D&A)", 0b1110000001000000}, // comp alone, with neither dest nor jump
+	}
+
+	for i, tt := range tests {
+		program, err := Assemble(strings.NewReader(tt.input), "test.asm")
+		if err != nil {
+			t.Fatalf("tests[%d]: %v", i, err)
+		}
+		if program.Words[0] != tt.expected {
+			t.Fatalf("tests[%d]: %v expected=%016b, but got=%016b", i, tt.input, tt.expected, program.Words[0])
+		}
+
+		_, err = AssembleWith(strings.NewReader(tt.input), "test.asm", Options{Strict: true})
+		if err == nil || !strings.Contains(err.Error(), "not allowed in strict mode") {
+			t.Fatalf("tests[%d]: expected %v to be rejected in strict mode, but got=%v", i, tt.input, err)
+		}
+	}
+}
+
+// every comp bit pattern has a mnemonic, which encodes back into it
+func TestExtendedCompMnemonic(t *testing.T) {
+	var code Code
+	code.Initialize()
+
+	for i := 0; i < 128; i++ {
+		bits := fmt.Sprintf("%07b", i)
+		mnemonic := code.ExtendedCompMnemonic(bits)
+		if code.ExtendedComp(mnemonic) != bits {
+			t.Fatalf("%v: mnemonic %v encodes into %q", bits, mnemonic, code.ExtendedComp(mnemonic))
+		}
+	}
+}
+
+func TestOperands(t *testing.T) {
+	input := `
+.equ ROW 0x20
+	@0x4000
+	@0b1010
+	@SCREEN+32
+	@SCREEN+ROW-1
+	@LOOP+1
+(LOOP)
+	@i+1
+	@32767
+	@0X7fff
+`
+	expected := []uint16{0x4000, 0b1010, 16416, 16415, 6, 17, 32767, 32767}
+
+	program, err := Assemble(strings.NewReader(input), "test.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(program.Words) != fmt.Sprint(expected) {
+		t.Fatalf("expected=%v, but got=%v", expected, program.Words)
+	}
+}
+
+func TestOperandErrors(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedOut []string
+	}{
+		{"@32768", []string{"test.asm:1:2: ", "does not fit in 15 bits"}},
+		{"@0x8000", []string{"test.asm:1:2: ", "does not fit in 15 bits"}},
+		{"@KBD+0x3000", []string{"test.asm:1:2: ", "value 36864"}},
+		{"@-1", []string{"test.asm:1:2: ", "value -1"}},
+		{"@SCREEN+0xZZ", []string{"test.asm:1:9: ", "could not parse 0xZZ"}},
+		{"@SCREEN+", []string{"test.asm:1:9: ", "expected a value or symbol"}},
+		{"@", []string{"test.asm:1:2: ", "needs a value or symbol"}},
+		{".equ BIG 40000\n@BIG", []string{"test.asm:2:2: ", "does not fit in 15 bits"}},
+	}
+
+	for i, tt := range tests {
+		_, err := Assemble(strings.NewReader(tt.input), "test.asm")
+		if err == nil {
+			t.Fatalf("tests[%d]: expected an error for %q", i, tt.input)
+		}
+		for _, out := range tt.expectedOut {
+			if !strings.Contains(err.Error(), out) {
+				t.Fatalf("tests[%d]: expected report to contain %q, but got=%v", i, out, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package assembler
+
+import (
+	"sort"
+	"strings"
+)
+
+type Code struct {
+	comp map[string]string
+	dest map[string]string
+	jump map[string]string
+
+	// inverse of above maps, key: bits, value: mnemonic
+	compOf map[string]string
+	destOf map[string]string
+	jumpOf map[string]string
+
+	// mnemonics accepted only outside of strict mode, see ExtendedComp
+	extComp   map[string]string
+	extCompOf map[string]string
+	extDest   map[string]string
+}
+
+// ALU functions outside of the 18 documented ones, other zx nx zy ny f no
+// combinations compute one of documented functions. Written with A, the
+// same functions with M are derived with a = 1. First mnemonic of a function
+// is its preferred name, others are accepted spellings.
+var extendedComp = []struct {
+	mnemonics []string
+	bits      string // cccccc
+}{
+	{[]string{"!(D&A)", "!D|!A", "!(A&D)", "!A|!D"}, "000001"},
+	{[]string{"-D-A-1", "-A-D-1", "!(D+A)", "!(A+D)"}, "000011"},
+	{[]string{"D&!A", "!A&D"}, "000100"},
+	{[]string{"!D|A", "A|!D"}, "000101"},
+	{[]string{"D-A-1"}, "000110"},
+	{[]string{"!D&A", "A&!D"}, "010000"},
+	{[]string{"D|!A", "!A|D"}, "010001"},
+	{[]string{"A-D-1"}, "010010"},
+	{[]string{"!(D|A)", "!D&!A", "!(A|D)", "!A&!D"}, "010100"},
+	{[]string{"-D-A-2", "-A-D-2"}, "010110"},
+	{[]string{"D+A+1", "A+D+1", "1+D+A", "1+A+D"}, "010111"},
+	{[]string{"-D-2"}, "011110"},
+	{[]string{"-A-2"}, "110110"},
+	{[]string{"-2"}, "111110"},
+}
+
+// commuted spellings of documented mnemonics, key: spelling, value: documented mnemonic
+var commutedComp = map[string]string{
+	"1+D":  "D+1",
+	"1+A":  "A+1",
+	"A+D":  "D+A",
+	"A&D":  "D&A",
+	"A|D":  "D|A",
+	"-1+D": "D-1",
+	"-1+A": "A-1",
+	"-A+D": "D-A",
+	"-D+A": "A-D",
+}
+
+func (c *Code) Initialize() {
+	c.comp = map[string]string{
+		// a = 0
+		// acccccc
+		"0":   "0101010",
+		"1":   "0111111",
+		"-1":  "0111010",
+		"D":   "0001100",
+		"A":   "0110000",
+		"!D":  "0001101",
+		"!A":  "0110001",
+		"-D":  "0001111",
+		"-A":  "0110011",
+		"D+1": "0011111",
+		"A+1": "0110111",
+		"D-1": "0001110",
+		"A-1": "0110010",
+		"D+A": "0000010",
+		"D-A": "0010011",
+		"A-D": "0000111",
+		"D&A": "0000000",
+		"D|A": "0010101",
+		// a = 1
+		"M":   "1110000",
+		"!M":  "1110001",
+		"-M":  "1110011",
+		"M+1": "1110111",
+		"M-1": "1110010",
+		"D+M": "1000010",
+		"D-M": "1010011",
+		"M-D": "1000111",
+		"D&M": "1000000",
+		"D|M": "1010101",
+	}
+
+	c.dest = map[string]string{
+		// ddd
+		"":    "000",
+		"M":   "001",
+		"D":   "010",
+		"MD":  "011",
+		"A":   "100",
+		"AM":  "101",
+		"AD":  "110",
+		"ADM": "111",
+	}
+
+	c.jump = map[string]string{
+		// jjj
+		"":    "000",
+		"JGT": "001",
+		"JEQ": "010",
+		"JGE": "011",
+		"JLT": "100",
+		"JNE": "101",
+		"JLE": "110",
+		"JMP": "111",
+	}
+
+	c.compOf = inverse(c.comp)
+	c.destOf = inverse(c.dest)
+	c.jumpOf = inverse(c.jump)
+	c.initializeExtended()
+}
+
+func (c *Code) initializeExtended() {
+	c.extComp = map[string]string{}
+	c.extCompOf = map[string]string{}
+	for _, function := range extendedComp {
+		for _, mnemonic := range function.mnemonics {
+			c.extComp[mnemonic] = "0" + function.bits
+			// same function on M, unless it does not depend on A
+			if strings.Contains(mnemonic, "A") {
+				c.extComp[strings.ReplaceAll(mnemonic, "A", "M")] = "1" + function.bits
+			}
+		}
+		preferred := function.mnemonics[0]
+		c.extCompOf["0"+function.bits] = preferred
+		if strings.Contains(preferred, "A") {
+			c.extCompOf["1"+function.bits] = strings.ReplaceAll(preferred, "A", "M")
+		}
+	}
+	for spelling, mnemonic := range commutedComp {
+		c.extComp[spelling] = c.comp[mnemonic]
+		c.extComp[strings.ReplaceAll(spelling, "A", "M")] = c.comp[strings.ReplaceAll(mnemonic, "A", "M")]
+	}
+
+	// any order of destination registers, eg: DM, MA, DMA
+	c.extDest = map[string]string{}
+	for mnemonic, bits := range c.dest {
+		for _, permutation := range permutations(mnemonic) {
+			c.extDest[permutation] = bits
+		}
+	}
+}
+
+func permutations(s string) []string {
+	if len(s) <= 1 {
+		return []string{s}
+	}
+	result := []string{}
+	for i := range s {
+		rest := s[:i] + s[i+1:]
+		for _, p := range permutations(rest) {
+			result = append(result, string(s[i])+p)
+		}
+	}
+	return result
+}
+
+func inverse(m map[string]string) map[string]string {
+	inv := make(map[string]string, len(m))
+	for mnemonic, bits := range m {
+		inv[bits] = mnemonic
+	}
+	return inv
+}
+
+func (c *Code) Dest(s string) string {
+	return c.dest[s]
+}
+
+func (c *Code) Comp(s string) string {
+	return c.comp[s]
+}
+
+func (c *Code) Jump(s string) string {
+	return c.jump[s]
+}
+
+// returns bits of comp mnemonics accepted outside of strict mode, that are
+// documented mnemonics, their commuted spellings like A+D or M&D, extended
+// ALU functions like D&!A, and raw bits %Bacccccc, eg: %B0000001 is !(D&A).
+// returns "" if s is none of them.
+func (c *Code) ExtendedComp(s string) string {
+	if bits, ok := c.comp[s]; ok {
+		return bits
+	}
+	if bits, ok := c.extComp[s]; ok {
+		return bits
+	}
+	if strings.HasPrefix(s, "%B") && len(s) == len("%B")+7 && strings.Trim(s[2:], "01") == "" {
+		return s[2:]
+	}
+	return ""
+}
+
+// returns documented comp mnemonics, ordered by their bits.
+func (c *Code) CompMnemonics() []string {
+	return mnemonicsOf(c.comp)
+}
+
+// returns documented dest mnemonics, "" (no dest) is left out.
+func (c *Code) DestMnemonics() []string {
+	return mnemonicsOf(c.dest)
+}
+
+// returns jump mnemonics, "" (no jump) is left out.
+func (c *Code) JumpMnemonics() []string {
+	return mnemonicsOf(c.jump)
+}
+
+func mnemonicsOf(m map[string]string) []string {
+	mnemonics := []string{}
+	for mnemonic := range m {
+		if mnemonic != "" {
+			mnemonics = append(mnemonics, mnemonic)
+		}
+	}
+	sort.Slice(mnemonics, func(i, j int) bool {
+		return m[mnemonics[i]] < m[mnemonics[j]]
+	})
+	return mnemonics
+}
+
+// returns bits of dest mnemonic, destination registers may be in any order.
+func (c *Code) ExtendedDest(s string) string {
+	return c.extDest[s]
+}
+
+// returns mnemonic of 7 comp bits acccccc, ok is false if bits encode no known mnemonic.
+func (c *Code) CompMnemonic(bits string) (mnemonic string, ok bool) {
+	mnemonic, ok = c.compOf[bits]
+	return mnemonic, ok
+}
+
+func (c *Code) DestMnemonic(bits string) (mnemonic string, ok bool) {
+	mnemonic, ok = c.destOf[bits]
+	return mnemonic, ok
+}
+
+func (c *Code) JumpMnemonic(bits string) (mnemonic string, ok bool) {
+	mnemonic, ok = c.jumpOf[bits]
+	return mnemonic, ok
+}
+
+// same as CompMnemonic, but bits that encode no documented mnemonic are
+// named as extended ALU function or written as raw %Bacccccc, so it always
+// returns a mnemonic accepted by ExtendedComp.
+func (c *Code) ExtendedCompMnemonic(bits string) string {
+	if mnemonic, ok := c.compOf[bits]; ok {
+		return mnemonic
+	}
+	if mnemonic, ok := c.extCompOf[bits]; ok {
+		return mnemonic
+	}
+	return "%B" + bits
+}
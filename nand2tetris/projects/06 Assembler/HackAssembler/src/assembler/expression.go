@@ -0,0 +1,136 @@
+package assembler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// largest value an A instruction can load, it has 15 bits for the value
+const maxAValue = 1<<15 - 1
+
+// error in operand of A instruction or in field of C instruction,
+// offset and length locate faulty text in it
+type operandError struct {
+	offset int
+	length int
+	errMsg string
+}
+
+func (e *operandError) Error() string {
+	return e.errMsg
+}
+
+// evaluates operand of A instruction, which is a sum of terms like SCREEN+32,
+// label-1 or 0x4000+0b11. A term is either a literal, decimal, hex with 0x or
+// binary with 0b, or a symbol whose address is given by resolve.
+// value must fit in 15 bits, otherwise *operandError is returned.
+func evaluateOperand(operand string, resolve func(symbol string) int) (int, error) {
+	value, err := sumOperand(operand, resolve)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkAValue(value, operand); err != nil {
+		return 0, err
+	}
+	return int(value), nil
+}
+
+// adds up terms of operand, value is not checked to fit in A instruction.
+func sumOperand(operand string, resolve func(symbol string) int) (int64, error) {
+	terms, err := parseOperand(operand)
+	if err != nil {
+		return 0, err
+	}
+	value := int64(0)
+	for _, t := range terms {
+		if t.symbol != "" {
+			value += t.sign * int64(resolve(t.symbol))
+		} else {
+			value += t.sign * t.value
+		}
+	}
+	return value, nil
+}
+
+// term of operand, either a symbol or a literal value, sign is 1 or -1
+type term struct {
+	sign   int64
+	symbol string
+	value  int64
+}
+
+// splits operand into terms joined by + and -.
+func parseOperand(operand string) ([]term, error) {
+	if strings.TrimSpace(operand) == "" {
+		return nil, &operandError{0, 1, "A instruction needs a value or symbol, eg: @17 or @LOOP"}
+	}
+
+	terms := []term{}
+	for start := 0; start < len(operand); {
+		sign := int64(1)
+		if operand[start] == '+' || operand[start] == '-' {
+			if operand[start] == '-' {
+				sign = -1
+			}
+			start++
+		}
+		end := start
+		for end < len(operand) && operand[end] != '+' && operand[end] != '-' {
+			end++
+		}
+
+		text := operand[start:end]
+		offset := start + len(text) - len(strings.TrimLeft(text, " \t"))
+		text = strings.TrimSpace(text)
+		switch {
+		case text == "":
+			return nil, &operandError{start, 1, "expected a value or symbol after + or -"}
+		case IsSymbol(text):
+			terms = append(terms, term{sign: sign, symbol: text})
+		default:
+			v, err := parseLiteral(text)
+			if err != nil {
+				errMsg := fmt.Sprintf("could not parse %v into integer or symbol", color.RedString(text))
+				return nil, &operandError{offset, len(text), errMsg}
+			}
+			terms = append(terms, term{sign: sign, value: v})
+		}
+		start = end
+	}
+	return terms, nil
+}
+
+// returns error for value of operand, if it does not fit in A instruction.
+func checkAValue(value int64, operand string) error {
+	if value < 0 || value > maxAValue {
+		errMsg := fmt.Sprintf("value %v of %v does not fit in 15 bits, A instruction loads 0 to %d",
+			color.RedString(strconv.FormatInt(value, 10)), operand, maxAValue)
+		return &operandError{0, len(operand), errMsg}
+	}
+	return nil
+}
+
+// parses decimal, hex 0x4000 or binary 0b1010 literal.
+func parseLiteral(text string) (int64, error) {
+	base, digits := 10, text
+	switch {
+	case strings.HasPrefix(text, "0x") || strings.HasPrefix(text, "0X"):
+		base, digits = 16, text[2:]
+	case strings.HasPrefix(text, "0b") || strings.HasPrefix(text, "0B"):
+		base, digits = 2, text[2:]
+	}
+	return strconv.ParseInt(digits, base, 32)
+}
+
+// returns symbols referenced by operand of A instruction, in order of appearance.
+func operandSymbols(operand string) []string {
+	symbols := []string{}
+	sumOperand(operand, func(symbol string) int {
+		symbols = append(symbols, symbol)
+		return 0
+	})
+	return symbols
+}
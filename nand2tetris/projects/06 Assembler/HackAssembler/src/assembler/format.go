@@ -0,0 +1,141 @@
+package assembler
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// formatted line, code and comment are empty for blank line
+type formatLine struct {
+	code     string
+	comment  string
+	indented bool
+}
+
+// Format returns src in canonical layout, the one CodeWriter of VMTranslator
+// writes: instructions and macro invocations are indented by a tab, label
+// declarations and directives are not. Spaces inside instructions are
+// removed, eg: D = M ; JGT -> D=M;JGT, unless they separate two symbols.
+// Trailing comments of consecutive lines are aligned, comment lines keep
+// the indentation of instructions if they were indented, runs of blank lines
+// are joined into one. Formatting changes layout only, not machine code.
+func Format(src io.Reader) ([]byte, error) {
+	text, err := io.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+	text = bytes.ReplaceAll(text, []byte("\r\n"), []byte("\n"))
+	lines := strings.Split(string(text), "\n")
+
+	sourceLines := make([]SourceLine, len(lines))
+	for i, line := range lines {
+		sourceLines[i] = SourceLine{Text: line, AtLine: i + 1}
+	}
+	var parser Parser
+	parser.Initialize(sourceLines)
+	types := map[int]int{} // key: line, value: type of its instruction
+	for parser.HasMoreLines() {
+		parser.Advance()
+		instrInfo := parser.GetInstrInfo()
+		types[instrInfo.AtLine] = instrInfo.Type
+	}
+
+	formatted := []formatLine{}
+	for i, line := range lines {
+		code, comment := stripComment(line), ""
+		if len(code) < len(line) {
+			comment = strings.TrimRightFunc(line[len(code):], unicode.IsSpace)
+		}
+		code = strings.TrimSpace(code)
+
+		f := formatLine{comment: comment}
+		switch instrType, ok := types[i+1]; {
+		case code == "":
+			f.indented = comment != "" && line != strings.TrimLeftFunc(line, unicode.IsSpace)
+		case strings.HasPrefix(code, "."):
+			f.code = strings.Join(strings.Fields(code), " ")
+			if at := strings.Index(code, "\""); at != -1 {
+				f.code = strings.Join(strings.Fields(code[:at]), " ") + " " + code[at:] // quoted path is kept
+			}
+		case ok && instrType == L_INSTRUCTION:
+			f.code = joinTokens(code)
+		case ok && (instrType == A_INSTRUCTION || instrType == C_INSTRUCTION):
+			f.code, f.indented = joinTokens(code), true
+		default:
+			// macro invocation, its arguments are separated by commas and spaces
+			f.code, f.indented = strings.Join(strings.Fields(code), " "), true
+		}
+		formatted = append(formatted, f)
+	}
+
+	var out bytes.Buffer
+	blank := false
+	for start := 0; start < len(formatted); {
+		f := formatted[start]
+		if f.code == "" && f.comment == "" {
+			blank = out.Len() > 0
+			start++
+			continue
+		}
+		if blank {
+			out.WriteString("\n")
+			blank = false
+		}
+		if f.code == "" {
+			writeLine(&out, f, 0)
+			start++
+			continue
+		}
+
+		// block of code lines with the same indentation, their comments are aligned
+		end, width := start, 0
+		for end < len(formatted) && formatted[end].code != "" && formatted[end].indented == f.indented {
+			if formatted[end].comment != "" {
+				width = max(width, len(formatted[end].code))
+			}
+			end++
+		}
+		for _, f := range formatted[start:end] {
+			writeLine(&out, f, width)
+		}
+		start = end
+	}
+	return out.Bytes(), nil
+}
+
+// writes f with comment at column width+1 after indentation.
+func writeLine(out *bytes.Buffer, f formatLine, width int) {
+	if f.indented {
+		out.WriteString("\t")
+	}
+	out.WriteString(f.code)
+	if f.comment != "" {
+		if f.code != "" {
+			out.WriteString(strings.Repeat(" ", width-len(f.code)+1))
+		}
+		out.WriteString(f.comment)
+	}
+	out.WriteString("\n")
+}
+
+// joins fields of instruction without spaces, except between two symbols
+// or literals, eg: "D = M ; JGT" -> "D=M;JGT", but "@a b" stays as it is.
+func joinTokens(code string) string {
+	var joined strings.Builder
+	for i, field := range strings.Fields(code) {
+		if i > 0 {
+			last := joined.String()[joined.Len()-1]
+			if isWordChar(last) && isWordChar(field[0]) {
+				joined.WriteString(" ")
+			}
+		}
+		joined.WriteString(field)
+	}
+	return joined.String()
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte(".$:_", c) != -1
+}
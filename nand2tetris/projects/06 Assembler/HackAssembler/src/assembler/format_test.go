@@ -0,0 +1,78 @@
+package assembler
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"  @i\nM = 0\n", "\t@i\n\tM=0\n"},
+		{"D = M ; JGT\r\n0 ;JMP\r\n", "\tD=M;JGT\n\t0;JMP\n"},
+		{"\t( LOOP )\n@LOOP + 1\n", "(LOOP)\n\t@LOOP+1\n"},
+		{"@i // counter\nD=M   // value\n\n@END // end\n", "\t@i  // counter\n\tD=M // value\n\n\t@END // end\n"},
+		{"(LOOP) // loop\n@LOOP // again\n", "(LOOP) // loop\n\t@LOOP // again\n"},
+		{"// header\n    // body\n@i\n", "// header\n\t// body\n\t@i\n"},
+		{"\n\n@1\n\n\n\n@2\n\n\n", "\t@1\n\n\t@2\n"},
+		{".equ   SIZE    32\n.include   \"my  lib.asm\"\n", ".equ SIZE 32\n.include \"my  lib.asm\"\n"},
+		{".macro INC  x\n@\\x\nM = M+1\n.endm\nINC   i\n", ".macro INC x\n\t@\\x\n\tM=M+1\n.endm\n\tINC i\n"},
+		{"@a b\nA M=1\n", "\t@a b\n\tA M=1\n"}, // spaces between symbols are kept
+	}
+
+	for i, tt := range tests {
+		got, err := Format(strings.NewReader(tt.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.expected {
+			t.Fatalf("tests[%d]: expected=%q, but got=%q", i, tt.expected, got)
+		}
+	}
+}
+
+// formatted program assembles into the same machine code and formatting it again changes nothing.
+func TestFormatFiles(t *testing.T) {
+	tests := []string{
+		"../../../add/Add.asm",
+		"../../../max/Max.asm",
+		"../../../rect/Rect.asm",
+		"../../../pong/Pong.asm",
+		"../../../../04 Machine Language/mult/Mult.asm",
+	}
+
+	for _, asmPath := range tests {
+		source, err := os.ReadFile(asmPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		formatted, err := Format(bytes.NewReader(source))
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := Format(bytes.NewReader(formatted))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(formatted, again) {
+			t.Fatalf("%v: formatting is not stable", asmPath)
+		}
+
+		expected, err := Assemble(bytes.NewReader(source), asmPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Assemble(bytes.NewReader(formatted), asmPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Words, expected.Words) {
+			t.Fatalf("%v: formatted program assembles into different machine code", asmPath)
+		}
+	}
+}
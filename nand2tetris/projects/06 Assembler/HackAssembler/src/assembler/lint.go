@@ -0,0 +1,144 @@
+package assembler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/ishwar00/HackAssembler/errHandler"
+)
+
+// registers beyond R15 do not exist, @R16 silently becomes a variable
+var registerLike = regexp.MustCompile(`^R[0-9]+$`)
+
+// checks assembled program for code which is valid, but is likely a mistake,
+// st must be symbol table after assembling it. Findings are added to errs as
+// warnings, or as errors if opts.Werror is set. Checks are:
+//   - @xxx used as jump target, while xxx is not a declared label, so it
+//     becomes a variable at RAM[16] onwards, usually a typo in label name
+//   - variables which look like predefined symbols or labels, eg: @R16, @sp, @loop
+//   - dest writing both A and M while comp does not read M, eg: AM=D+1
+//     writes M at the address A held before the instruction, not at the new A.
+//     AM=M-1 is fine, it reads and writes the same register
+//   - jump in the same instruction that writes A, eg: A=D;JMP jumps to old A
+//   - label loaded for a jump, but A is rewritten before the jump
+//   - labels which are declared but never used
+func lint(parser Parser, st SymbolTable, opts Options, errs *errhandler.ErrHandler) {
+	warn := func(e errhandler.Error) {
+		if opts.Werror {
+			e.ErrMsg += " [-Werror]"
+		} else {
+			e.Warning = true
+		}
+		errs.Add(e)
+	}
+
+	// key: symbol in lower case, value: symbol
+	labelFold, predefinedFold := map[string]string{}, map[string]string{}
+	for _, label := range st.Symbols(LABEL_SYMBOL) {
+		labelFold[strings.ToLower(label)] = label
+	}
+	for _, symbol := range st.Symbols(PREDEFINED_SYMBOL) {
+		predefinedFold[strings.ToLower(symbol)] = symbol
+	}
+
+	used := map[string]bool{}                   // symbols referenced by A instructions
+	warned := map[string]bool{}                 // variables which are already warned about
+	declaredAt := map[string]errhandler.Error{} // key: label, value: location of its declaration
+
+	// last A instruction, while A still holds what it loaded
+	var load InstructionInfo
+	var loadAt errhandler.Error
+	var loadSymbols []string
+	hasLoad, loadOverwritten := false, false
+
+	for parser.HasMoreLines() {
+		parser.Advance()
+		switch parser.InstructionType() {
+		case L_INSTRUCTION:
+			// a jump to label may arrive with anything in A
+			hasLoad = false
+			symbol := parser.Symbol()
+			declaredAt[symbol] = parser.ErrorAt(1, len(symbol), "")
+
+		case A_INSTRUCTION:
+			operand := parser.Symbol()
+			symbols := operandSymbols(operand)
+			for _, symbol := range symbols {
+				used[symbol] = true
+				if st.Kind(symbol) != VARIABLE_SYMBOL || warned[symbol] {
+					continue
+				}
+				at := parser.ErrorAt(1+strings.Index(operand, symbol), len(symbol), "")
+				address := st.GetAddress(symbol)
+				switch {
+				case registerLike.MatchString(symbol):
+					at.ErrMsg = fmt.Sprintf("%v is not a register, only R0 to R15 are predefined, it becomes a variable at RAM[%d]",
+						color.YellowString(symbol), address)
+				case predefinedFold[strings.ToLower(symbol)] != "":
+					at.ErrMsg = fmt.Sprintf("variable %v is not predefined symbol %v, symbols are case sensitive",
+						color.YellowString(symbol), predefinedFold[strings.ToLower(symbol)])
+				case labelFold[strings.ToLower(symbol)] != "":
+					at.ErrMsg = fmt.Sprintf("variable %v is not label %v, symbols are case sensitive",
+						color.YellowString(symbol), labelFold[strings.ToLower(symbol)])
+				default:
+					continue
+				}
+				warn(at)
+				warned[symbol] = true
+			}
+			load, loadSymbols = parser.GetInstrInfo(), symbols
+			loadAt = parser.ErrorAt(1, len(operand), "")
+			hasLoad, loadOverwritten = true, false
+
+		case C_INSTRUCTION:
+			instr := parser.GetInstrInfo().Instr
+			dest, comp, jump := parser.Dest(), parser.Comp(), parser.Jump()
+			writesA := strings.Contains(dest, "A")
+			if writesA && strings.Contains(dest, "M") && !strings.Contains(comp, "M") {
+				errMsg := fmt.Sprintf("%v writes M at the address A held before this instruction, not at the new value of A",
+					color.YellowString(dest))
+				warn(parser.ErrorAt(0, len(dest), errMsg))
+			}
+
+			if jump != "" {
+				switch {
+				case writesA:
+					errMsg := fmt.Sprintf("%v jumps to the address A held before this instruction, not to the new value of A",
+						color.YellowString(instr))
+					warn(parser.ErrorAt(0, len(instr), errMsg))
+				case hasLoad && loadOverwritten:
+					for _, symbol := range loadSymbols {
+						if st.Kind(symbol) == LABEL_SYMBOL {
+							errMsg := fmt.Sprintf("jump does not go to %v loaded at line %d, A is rewritten before the jump",
+								color.YellowString(symbol), load.AtLine)
+							warn(parser.ErrorAt(0, len(instr), errMsg))
+							break
+						}
+					}
+				case hasLoad:
+					for _, symbol := range loadSymbols {
+						if st.Kind(symbol) == VARIABLE_SYMBOL && !warned[symbol] {
+							at := loadAt
+							at.ErrMsg = fmt.Sprintf("%v is not a declared label, it becomes a variable at RAM[%d] and is used as jump target",
+								color.YellowString(symbol), st.GetAddress(symbol))
+							warn(at)
+							warned[symbol] = true
+						}
+					}
+				}
+			}
+			if writesA {
+				loadOverwritten = true
+			}
+		}
+	}
+
+	for _, label := range st.Symbols(LABEL_SYMBOL) {
+		if at, ok := declaredAt[label]; ok && !used[label] {
+			at.ErrMsg = fmt.Sprintf("label %v is declared but never used", color.YellowString(label))
+			warn(at)
+		}
+	}
+}
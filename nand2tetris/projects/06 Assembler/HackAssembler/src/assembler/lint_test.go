@@ -0,0 +1,68 @@
+package assembler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ishwar00/HackAssembler/errHandler"
+)
+
+func TestWarnings(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedCount int
+		expectedOut   []string
+	}{
+		// label typo becomes a variable used as jump target
+		{"(LOOP)\n@LOOP\n0;JMP\n@LOPO\n0;JMP", 1, []string{"test.asm:4:2: ", "LOPO is not a declared label"}},
+		{"@R16\nM=0", 1, []string{"test.asm:1:2: ", "only R0 to R15 are predefined"}},
+		{"@sp\nM=0", 1, []string{"test.asm:1:2: ", "is not predefined symbol SP"}},
+		{"(LOOP)\n@LOOP\n0;JMP\n@loop\nM=0", 1, []string{"test.asm:4:2: ", "is not label LOOP"}},
+		{"@R1\nAM=D+1", 1, []string{"test.asm:2:1: ", "writes M at the address A held before"}},
+		{"@R1\nA=D;JMP", 1, []string{"test.asm:2:1: ", "jumps to the address A held before"}},
+		{"(END)\n@END\nA=M\n0;JMP", 1, []string{"test.asm:4:1: ", "jump does not go to END loaded at line 2"}},
+		{"(UNUSED)\n@R0\nM=0", 1, []string{"test.asm:1:2: ", "label UNUSED is declared but never used"}},
+		// common idioms are not warned about
+		{"@SP\nAM=M-1\nD=M\n@R13\nA=M\n0;JMP\n(END)\n@END+0\n0;JMP\n@i\nM=0", 0, nil},
+	}
+
+	for i, tt := range tests {
+		program, err := Assemble(strings.NewReader(tt.input), "test.asm")
+		if err != nil {
+			t.Fatalf("tests[%d]: unexpected error %v", i, err)
+		}
+
+		if tt.expectedCount == 0 {
+			if program.Warnings != nil {
+				t.Fatalf("tests[%d]: expected no warnings, but got=%v", i, program.Warnings)
+			}
+			continue
+		}
+		if program.Warnings == nil || program.Warnings.Warning_count() != tt.expectedCount {
+			t.Fatalf("tests[%d]: expected %d warnings, but got=%v", i, tt.expectedCount, program.Warnings)
+		}
+
+		report := program.Warnings.Error()
+		for _, out := range append(tt.expectedOut, "warning") {
+			if !strings.Contains(report, out) {
+				t.Fatalf("tests[%d]: expected report to contain %q, but got=%v", i, out, report)
+			}
+		}
+	}
+}
+
+func TestWerror(t *testing.T) {
+	input := "(UNUSED)\n@R16\nM=0"
+	_, err := AssembleWith(strings.NewReader(input), "test.asm", Options{Werror: true})
+	if err == nil {
+		t.Fatalf("expected warnings to fail assembly with Werror")
+	}
+
+	errs, ok := err.(*errhandler.ErrHandler)
+	if !ok {
+		t.Fatalf("expected *errhandler.ErrHandler, but got=%T", err)
+	}
+	if errs.Error_count() != 2 || !strings.Contains(err.Error(), "[-Werror]") {
+		t.Fatalf("expected 2 errors marked with [-Werror], but got=%v", err)
+	}
+}
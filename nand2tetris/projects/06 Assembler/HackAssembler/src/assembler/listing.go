@@ -0,0 +1,91 @@
+package assembler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// writes a human readable listing of program, every instruction is shown with its
+// ROM address, binary and hex encoding and the source line it came from, label
+// declarations are shown at the address they resolve to, followed by instructions
+// removed by optimizer, if any. Listing ends with
+// resolved symbol table, labels with ROM addresses, constants with their values
+// and variables with RAM addresses. Program needs Instrs, so one assembled by
+// AssembleStream can not be listed.
+func WriteListing(w io.Writer, program Program) error {
+	if len(program.Instrs) != len(program.Words) {
+		return fmt.Errorf("listing of %v needs its instructions, program assembled in a single pass has none", program.Name)
+	}
+	bw := bufio.NewWriter(w)
+
+	// key: ROM address, value: labels declared at that address
+	labelsAt := map[int][]string{}
+	for _, label := range program.Symbols.Symbols(LABEL_SYMBOL) {
+		address := program.Symbols.GetAddress(label)
+		labelsAt[address] = append(labelsAt[address], label)
+	}
+
+	fmt.Fprintf(bw, "// listing of %s\n", program.Name)
+	fmt.Fprintf(bw, "%5s  %-16s  %4s  %5s  %s\n", "ROM", "binary", "hex", "line", "source")
+	for address, word := range program.Words {
+		for _, label := range labelsAt[address] {
+			fmt.Fprintf(bw, "%5d  %-16s  %4s  %5s  (%s)\n", address, "", "", "", label)
+		}
+		instrInfo := program.Instrs[address]
+		fmt.Fprintf(bw, "%5d  %016b  %04X  %5s  %s\n",
+			address, word, word, sourceLine(program, instrInfo), sourceText(program, instrInfo))
+	}
+	// labels declared after last instruction, eg: (END) at the end of program
+	for _, label := range labelsAt[len(program.Words)] {
+		fmt.Fprintf(bw, "%5d  %-16s  %4s  %5s  (%s)\n", len(program.Words), "", "", "", label)
+	}
+
+	if len(program.Removed) > 0 {
+		fmt.Fprintf(bw, "\n// removed by optimizer\n")
+		fmt.Fprintf(bw, "%5s  %s\n", "line", "source")
+		for _, instrInfo := range program.Removed {
+			fmt.Fprintf(bw, "%5s  %s\n", sourceLine(program, instrInfo), sourceText(program, instrInfo))
+		}
+	}
+
+	fmt.Fprintf(bw, "\n// labels\n")
+	fmt.Fprintf(bw, "%5s  %s\n", "ROM", "symbol")
+	for _, label := range program.Symbols.Symbols(LABEL_SYMBOL) {
+		fmt.Fprintf(bw, "%5d  %s\n", program.Symbols.GetAddress(label), label)
+	}
+
+	fmt.Fprintf(bw, "\n// constants\n")
+	fmt.Fprintf(bw, "%5s  %s\n", "value", "symbol")
+	for _, constant := range program.Symbols.Symbols(CONSTANT_SYMBOL) {
+		fmt.Fprintf(bw, "%5d  %s\n", program.Symbols.GetAddress(constant), constant)
+	}
+
+	fmt.Fprintf(bw, "\n// variables\n")
+	fmt.Fprintf(bw, "%5s  %s\n", "RAM", "symbol")
+	for _, variable := range program.Symbols.Symbols(VARIABLE_SYMBOL) {
+		fmt.Fprintf(bw, "%5d  %s\n", program.Symbols.GetAddress(variable), variable)
+	}
+	return bw.Flush()
+}
+
+// line number of instruction, prefixed with file name if instruction is from included file.
+func sourceLine(program Program, instrInfo InstructionInfo) string {
+	if instrInfo.InFile != program.Name {
+		return fmt.Sprintf("%s:%d", filepath.Base(instrInfo.InFile), instrInfo.AtLine)
+	}
+	return strconv.Itoa(instrInfo.AtLine)
+}
+
+// source line of instruction as written, including comment if any.
+// for instruction produced by macro expansion, it is expanded instruction.
+func sourceText(program Program, instrInfo InstructionInfo) string {
+	source := program.Source[instrInfo.InFile]
+	if at := instrInfo.AtLine - 1; instrInfo.Macro == "" && at >= 0 && at < len(source) {
+		return strings.TrimSpace(source[at])
+	}
+	return instrInfo.Instr
+}
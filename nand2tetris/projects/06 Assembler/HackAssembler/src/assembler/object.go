@@ -0,0 +1,167 @@
+package assembler
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/ishwar00/HackAssembler/errHandler"
+	"github.com/ishwar00/HackAssembler/object"
+)
+
+// AssembleObject translates Hack assembly read from src into a relocatable
+// module, which hacklink combines with other modules into a program.
+//
+// Labels of module are known, A instructions referring to them are relocated
+// by ROM address of module. Unless module lists its exports with .export
+// directive, labels with a dot are exported, they are VM functions Foo.bar and
+// their labels Foo.bar$ret.0, others, eg: TRUE_0 of comparisons, which VM
+// translator numbers from 0 in every module, stay private. A label of another
+// module is used by declaring it with .import. Symbols which are neither
+// declared, imported nor predefined are variables of the module, linker
+// allocates them in order of first use as Assemble does.
+//
+// Linking modules gives the same program as assembling their concatenation,
+// if they import every label of other modules they use and no two modules
+// have a variable of the same name. opts.Optimize is not supported.
+func AssembleObject(src io.Reader, name string, opts Options) (object.Object, error) {
+	if opts.Optimize {
+		return object.Object{}, fmt.Errorf("%v: optimizer needs the whole program, it can not be used for objects", name)
+	}
+
+	var code Code
+	var st SymbolTable
+	var errs errhandler.ErrHandler
+	code.Initialize()
+	st.InitializeWith(opts.target())
+	preprocessor, parser, err := parseSource(src, name, opts, &errs)
+	if err != nil {
+		return object.Object{}, err
+	}
+	addConstants(&st, preprocessor.Constants, &errs)
+	st.DoPass1(parser, &errs)
+
+	obj := object.Object{
+		Name:    name,
+		Code:    []object.Word{},
+		Exports: map[string]int{},
+		Imports: []string{},
+		Externs: []string{},
+	}
+	checkDeclarations(preprocessor.Exports, st, &errs, exportNotDeclared)
+	for _, declaration := range preprocessor.Exports {
+		obj.Exports[declaration.Name] = st.GetAddress(declaration.Name)
+	}
+	if len(preprocessor.Exports) == 0 {
+		for _, label := range st.Symbols(LABEL_SYMBOL) {
+			if strings.Contains(label, ".") {
+				obj.Exports[label] = st.GetAddress(label)
+			}
+		}
+	}
+
+	imported := map[string]bool{}
+	for _, declaration := range preprocessor.Imports {
+		if st.Contains(declaration.Name) {
+			errMsg := fmt.Sprintf("%v is imported, but it is declared in this module", color.RedString(declaration.Name))
+			errs.Add(declarationError(declaration, errMsg))
+			continue
+		}
+		if !imported[declaration.Name] {
+			obj.Imports = append(obj.Imports, declaration.Name)
+			imported[declaration.Name] = true
+		}
+	}
+	external := map[string]bool{}
+
+	for parser.HasMoreLines() {
+		parser.Advance()
+		switch parser.InstructionType() {
+		case L_INSTRUCTION:
+			continue
+
+		case A_INSTRUCTION:
+			operand := parser.Symbol()
+			terms, err := parseOperand(operand)
+			if err != nil {
+				e := err.(*operandError)
+				errs.Add(parser.ErrorAt(1+e.offset, e.length, e.errMsg))
+				continue
+			}
+
+			word := object.Word{}
+			value := int64(0)
+			for _, t := range terms {
+				switch {
+				case t.symbol == "":
+					value += t.sign * t.value
+				case st.Kind(t.symbol) == LABEL_SYMBOL && st.Contains(t.symbol):
+					value += t.sign * int64(st.GetAddress(t.symbol))
+					word.Relocs = append(word.Relocs, object.Reloc{Sign: int(t.sign)})
+				case st.Contains(t.symbol):
+					value += t.sign * int64(st.GetAddress(t.symbol))
+				default:
+					word.Relocs = append(word.Relocs, object.Reloc{Sign: int(t.sign), Symbol: t.symbol})
+					if !imported[t.symbol] && !external[t.symbol] {
+						obj.Externs = append(obj.Externs, t.symbol)
+						external[t.symbol] = true
+					}
+				}
+			}
+			if len(word.Relocs) == 0 {
+				if err := checkAValue(value, operand); err != nil {
+					e := err.(*operandError)
+					errs.Add(parser.ErrorAt(1+e.offset, e.length, e.errMsg))
+					continue
+				}
+			}
+			word.Value = int(value)
+			obj.Code = append(obj.Code, word)
+
+		case C_INSTRUCTION:
+			word, cErrs := encodeC(code, parser.GetInstrInfo().Instr, opts)
+			for _, e := range cErrs {
+				errs.Add(parser.ErrorAt(e.offset, e.length, e.errMsg))
+			}
+			obj.Code = append(obj.Code, object.Word{Value: int(word)})
+
+		default:
+			instr := parser.GetInstrInfo().Instr
+			errs.Add(parser.ErrorAt(0, len(instr), "alien instruction: failed to classify the instruction"))
+		}
+	}
+
+	if errs.Error_count() > 0 {
+		return object.Object{}, &errs
+	}
+	return obj, nil
+}
+
+// errors of checkDeclarations, they get name of the declared label
+const (
+	exportNotDeclared = "%v is exported, but it is not a declared label"
+	importNotDeclared = "imported label %v is not declared, link module with hacklink"
+)
+
+// adds error for every declaration which does not name a label declared in
+// program, errFormat gets the name.
+func checkDeclarations(declarations []Declaration, st SymbolTable, errs *errhandler.ErrHandler, errFormat string) {
+	for _, declaration := range declarations {
+		if st.Kind(declaration.Name) != LABEL_SYMBOL || !st.Contains(declaration.Name) {
+			errMsg := fmt.Sprintf(errFormat, color.RedString(declaration.Name))
+			errs.Add(declarationError(declaration, errMsg))
+		}
+	}
+}
+
+func declarationError(declaration Declaration, errMsg string) errhandler.Error {
+	line := declaration.Line
+	return errhandler.Error{
+		ErrMsg:   errMsg,
+		OnLine:   line.AtLine - 1,
+		OnColumn: max(0, strings.Index(line.Text, declaration.Name)),
+		Length:   len(declaration.Name),
+		File:     line.File,
+	}
+}
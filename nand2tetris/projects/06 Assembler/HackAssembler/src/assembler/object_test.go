@@ -0,0 +1,76 @@
+package assembler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ishwar00/HackAssembler/object"
+)
+
+func TestAssembleObject(t *testing.T) {
+	input := `.equ SIZE 32
+.export LOOP
+.import Sys.init
+(START)
+	@SIZE
+	D=A
+(LOOP)
+	@LOOP+1
+	@i
+	@Sys.init
+	0;JMP
+`
+	expected := object.Object{
+		Name: "test.asm",
+		Code: []object.Word{
+			{Value: 32},
+			{Value: 0b1110110000010000},
+			{Value: 3, Relocs: []object.Reloc{{Sign: 1}}},
+			{Value: 0, Relocs: []object.Reloc{{Sign: 1, Symbol: "i"}}},
+			{Value: 0, Relocs: []object.Reloc{{Sign: 1, Symbol: "Sys.init"}}},
+			{Value: 0b1110101010000111},
+		},
+		Exports: map[string]int{"LOOP": 2},
+		Imports: []string{"Sys.init"},
+		Externs: []string{"i"},
+	}
+
+	obj, err := AssembleObject(strings.NewReader(input), "test.asm", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(obj, expected) {
+		t.Fatalf("expected=%+v, but got=%+v", expected, obj)
+	}
+}
+
+func TestAssembleObjectErrors(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedErr string
+	}{
+		{".export END\n@END\n", "test.asm:1:9: "},
+		{".export END\n@END\n", "is exported, but it is not a declared label"},
+		{".import LOOP\n(LOOP)\n", "is imported, but it is declared in this module"},
+		{"@40000\n", "test.asm:1:2: "},
+		{"AD=X\n", "unknown comp"},
+	}
+
+	for i, tt := range tests {
+		_, err := AssembleObject(strings.NewReader(tt.input), "test.asm", Options{})
+		if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+			t.Fatalf("tests[%d]: expected error %q, but got=%v", i, tt.expectedErr, err)
+		}
+	}
+
+	// whole program may declare exports and imports, but they must be its labels
+	_, err := Assemble(strings.NewReader(".export LOOP\n.import Sys.init\n(LOOP)\n@Sys.init\n"), "test.asm")
+	if err == nil || !strings.Contains(err.Error(), "is not declared, link module with hacklink") {
+		t.Fatalf("expected error for imported label, but got=%v", err)
+	}
+	_, err = Assemble(strings.NewReader(".export LOOP\n.import Sys.init\n(LOOP)\n(Sys.init)\n@LOOP\n"), "test.asm")
+	if err != nil {
+		t.Fatalf("expected declared labels to assemble, but got=%v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package assembler
+
+import "strings"
+
+// peephole optimizer, it works on parsed instructions before labels are
+// resolved, so labels are bound to addresses of remaining instructions.
+// Instructions are only removed, never rewritten, so every remaining
+// instruction still maps to the source line it came from.
+//
+// Rules, applied until none of them removes anything:
+//   - unreachable code: instructions after an unconditional jump, up to next
+//     label declaration, can not be executed.
+//   - dead A-loads: @x followed by another A instruction, with only label
+//     declarations in between, has no effect because A is overwritten.
+//   - reloads: @x repeated while A still holds x, that is, no label declaration
+//     and no instruction writing A in between.
+//   - push/pop pairs: M=M+1 right after M=M-1, or the other way around, leaves
+//     M unchanged, eg: @SP M=M+1 @SP M=M-1 folds away completely.
+//
+// An operand counting instructions from a label, eg: @LOOP+2, or a jump to an
+// address not given by a label alone, eg: @17 0;JMP, assumes the layout of
+// program stays as written, program using one is not optimized.
+
+// removes instructions which do not change the behaviour of program,
+// returns removed instructions in the order they appeared.
+func (p *Parser) Optimize() []InstructionInfo {
+	if dependsOnLayout(p.instrList) {
+		return nil
+	}
+	var removed []InstructionInfo
+	p.instrList, removed = optimize(p.instrList)
+	p.totalInstr = len(p.instrList)
+	p.nextInstr = -1
+	return removed
+}
+
+func optimize(instrList []InstructionInfo) ([]InstructionInfo, []InstructionInfo) {
+	rules := []func(instrList []InstructionInfo, live []int, drop []bool){
+		dropUnreachable,
+		dropDeadLoads,
+		dropReloads,
+		foldIncDec,
+	}
+
+	drop := make([]bool, len(instrList))
+	for changed := true; changed; {
+		changed = false
+		for _, rule := range rules {
+			// indices of instructions not dropped yet
+			live := []int{}
+			for i := range instrList {
+				if !drop[i] {
+					live = append(live, i)
+				}
+			}
+			rule(instrList, live, drop)
+			for _, i := range live {
+				changed = changed || drop[i]
+			}
+		}
+	}
+
+	kept, removed := []InstructionInfo{}, []InstructionInfo{}
+	for i, instrInfo := range instrList {
+		if drop[i] {
+			removed = append(removed, instrInfo)
+		} else {
+			kept = append(kept, instrInfo)
+		}
+	}
+	return kept, removed
+}
+
+// true if an A instruction combines a label with other terms, eg: @L+2 or
+// @END-START, or a jump goes to an address not given by a label alone, eg:
+// @17 0;JMP, removing instructions would change what they point at.
+func dependsOnLayout(instrList []InstructionInfo) bool {
+	labels := map[string]bool{}
+	for _, instrInfo := range instrList {
+		if instrInfo.Type == L_INSTRUCTION {
+			labels[strings.TrimSuffix(strings.TrimPrefix(instrInfo.Instr, "("), ")")] = true
+		}
+	}
+	for i, instrInfo := range instrList {
+		if instrInfo.Type != A_INSTRUCTION {
+			continue
+		}
+		terms, err := parseOperand(strings.TrimPrefix(instrInfo.Instr, "@"))
+		if err != nil {
+			continue
+		}
+		isLabel := len(terms) == 1 && terms[0].sign == 1 && labels[terms[0].symbol]
+		if !isLabel && feedsJump(instrList, i) {
+			return true
+		}
+		if len(terms) < 2 {
+			continue
+		}
+		for _, t := range terms {
+			if labels[t.symbol] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// true if a jump follows A instruction instrList[i] while A still holds its
+// value, jump goes to address A had before the jumping instruction.
+func feedsJump(instrList []InstructionInfo, i int) bool {
+	for _, instrInfo := range instrList[i+1:] {
+		switch instrInfo.Type {
+		case A_INSTRUCTION:
+			return false
+		case C_INSTRUCTION:
+			dest, _, jump := splitC(instrInfo.Instr)
+			if jump != "" {
+				return true
+			}
+			if strings.Contains(dest, "A") {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+func dropUnreachable(instrList []InstructionInfo, live []int, drop []bool) {
+	unreachable := false
+	for _, i := range live {
+		switch instrInfo := instrList[i]; instrInfo.Type {
+		case L_INSTRUCTION:
+			unreachable = false
+		case C_INSTRUCTION:
+			if unreachable {
+				drop[i] = true
+			}
+			_, _, jump := splitC(instrInfo.Instr)
+			unreachable = unreachable || jump == "JMP"
+		default:
+			if unreachable {
+				drop[i] = true
+			}
+		}
+	}
+}
+
+func dropDeadLoads(instrList []InstructionInfo, live []int, drop []bool) {
+	for k, i := range live {
+		if instrList[i].Type != A_INSTRUCTION {
+			continue
+		}
+		next := k + 1
+		for next < len(live) && instrList[live[next]].Type == L_INSTRUCTION {
+			next++
+		}
+		if next < len(live) && instrList[live[next]].Type == A_INSTRUCTION {
+			drop[i] = true
+		}
+	}
+}
+
+func dropReloads(instrList []InstructionInfo, live []int, drop []bool) {
+	for k := 0; k < len(live); k++ {
+		load := instrList[live[k]]
+		if load.Type != A_INSTRUCTION {
+			continue
+		}
+		for k+1 < len(live) {
+			instrInfo := instrList[live[k+1]]
+			if instrInfo.Type == A_INSTRUCTION && instrInfo.Instr == load.Instr {
+				drop[live[k+1]] = true
+				k++
+				continue
+			}
+			if dest, _, _ := splitC(instrInfo.Instr); instrInfo.Type != C_INSTRUCTION || strings.Contains(dest, "A") {
+				break
+			}
+			k++
+		}
+	}
+}
+
+func foldIncDec(instrList []InstructionInfo, live []int, drop []bool) {
+	for k := 0; k+1 < len(live); k++ {
+		first, second := instrList[live[k]].Instr, instrList[live[k+1]].Instr
+		if (first == "M=M+1" && second == "M=M-1") || (first == "M=M-1" && second == "M=M+1") {
+			drop[live[k]], drop[live[k+1]] = true, true
+			k++
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package assembler
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ishwar00/HackAssembler/errHandler"
+)
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string // remaining instructions
+		removed  int
+	}{
+		// dead A-load, even across label declaration
+		{"@1\n@2\nD=A\n@3\n(L)\n@4\nD=D+A\n", []string{"@2", "D=A", "(L)", "@4", "D=D+A"}, 2},
+		// reload of @SP, A is not written in between
+		{"@SP\nA=M\nM=D\n@SP\nM=M+1\n@SP\nD=M\n", []string{"@SP", "A=M", "M=D", "@SP", "M=M+1", "D=M"}, 1},
+		// label declaration in between stops reload removal
+		{"@SP\nM=M+1\n(L)\n@SP\nM=M+1\n", []string{"@SP", "M=M+1", "(L)", "@SP", "M=M+1"}, 0},
+		// push/pop pair folds away, then @SP becomes dead load
+		{"D=A\n@SP\nM=M+1\n@SP\nM=M-1\n@R13\nM=D\n", []string{"D=A", "@R13", "M=D"}, 4},
+		// unreachable code after unconditional jump, up to next label
+		{"@END\n0;JMP\nD=A\n@5\nM=D\n(END)\n@END\nD;JMP\n", []string{"@END", "0;JMP", "(END)", "@END", "D;JMP"}, 3},
+		// conditional jump does not make following code unreachable
+		{"@END\nD;JGT\nD=A\n(END)\n", []string{"@END", "D;JGT", "D=A", "(END)"}, 0},
+	}
+
+	for i, test := range tests {
+		var parser Parser
+		var preprocessor Preprocessor
+		var errs errhandler.ErrHandler
+		preprocessor.Initialize(nil, &errs)
+		lines, err := preprocessor.Process(strings.NewReader(test.input), "test.asm")
+		if err != nil {
+			t.Fatal(err)
+		}
+		parser.Initialize(lines)
+		removed := parser.Optimize()
+
+		got := []string{}
+		for parser.HasMoreLines() {
+			parser.Advance()
+			got = append(got, parser.GetInstrInfo().Instr)
+		}
+		if strings.Join(got, " ") != strings.Join(test.expected, " ") {
+			t.Fatalf("tests[%d]: expected=%q, but got=%q", i, test.expected, got)
+		}
+		if len(removed) != test.removed {
+			t.Fatalf("tests[%d]: expected %d removed instructions, but got=%d", i, test.removed, len(removed))
+		}
+	}
+}
+
+func TestAssembleOptimized(t *testing.T) {
+	input := `
+	@SP
+	M=M+1
+	@SP
+	M=M-1
+(LOOP)
+	@LOOP
+	0;JMP
+	@unreachable
+	M=0
+(END)
+	@END
+	0;JMP
+`
+	program, err := AssembleWith(strings.NewReader(input), "test.asm", Options{Optimize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// labels resolve to addresses after removal
+	expected := []uint16{0, 0b1110101010000111, 2, 0b1110101010000111}
+	if len(program.Words) != len(expected) {
+		t.Fatalf("expected=%v, but got=%v", expected, program.Words)
+	}
+	for i, word := range expected {
+		if program.Words[i] != word {
+			t.Fatalf("Words[%d]: expected=%016b, but got=%016b", i, word, program.Words[i])
+		}
+	}
+	if program.Symbols.Contains("unreachable") {
+		t.Fatalf("expected removed instruction to allocate no variable")
+	}
+
+	// every remaining word maps to its source line
+	expectedLines := []int{7, 8, 12, 13}
+	for i, line := range expectedLines {
+		if program.Instrs[i].AtLine != line {
+			t.Fatalf("Instrs[%d]: expected AtLine=%d, but got=%d", i, line, program.Instrs[i].AtLine)
+		}
+	}
+	if len(program.Removed) != 6 {
+		t.Fatalf("expected 6 removed instructions, but got=%d", len(program.Removed))
+	}
+
+	var listing bytes.Buffer
+	if err := WriteListing(&listing, program); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(listing.String(), "// removed by optimizer\n line  source\n    2  @SP\n") {
+		t.Fatalf("expected removed instructions in listing, but got=\n%v", listing.String())
+	}
+
+	// errors in instructions which optimizer would remove are still reported
+	_, err = AssembleWith(strings.NewReader("@END\n0;JMP\nD=X\n(END)\n"), "test.asm", Options{Optimize: true})
+	if err == nil {
+		t.Fatalf("expected error in unreachable instruction")
+	}
+}
+
+func TestOptimizeFiles(t *testing.T) {
+	for _, asmPath := range []string{"../../../pong/Pong.asm", "../../../rect/Rect.asm"} {
+		source, err := os.ReadFile(asmPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plain, err := Assemble(bytes.NewReader(source), asmPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		optimized, err := AssembleWith(bytes.NewReader(source), asmPath, Options{Optimize: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(optimized.Words)+len(optimized.Removed) != len(plain.Words) {
+			t.Fatalf("%v: expected %d words plus removed, but got=%d+%d",
+				asmPath, len(plain.Words), len(optimized.Words), len(optimized.Removed))
+		}
+	}
+}
+
+func TestOptimizeKeepsAddresses(t *testing.T) {
+	tests := []struct {
+		input   string
+		removed int
+	}{
+		// @L+2 counts instructions from L, program is not optimized
+		{"@L+2\n0;JMP\n(L)\n@1\n@2\nD=A\n@3\nD=A\n", 0},
+		{"@END-L\nD=A\n(L)\n@1\n@2\nD=A\n(END)\n", 0},
+		// @6 is ROM address of D=M, program is not optimized
+		{"@6\nD;JGT\n@1\n@2\nD=A\n@3\nD=M\n", 0},
+		{"@R1\nD=D-1\n0;JMP\n@1\n@2\nD=A\n", 0},
+		// dead @a is removed, but b and c keep their RAM addresses
+		{"@a\n@b\nM=D\n@c\nM=D\n@a\nM=0\n", 1},
+	}
+
+	for i, test := range tests {
+		plain, err := Assemble(strings.NewReader(test.input), "test.asm")
+		if err != nil {
+			t.Fatal(err)
+		}
+		optimized, err := AssembleWith(strings.NewReader(test.input), "test.asm", Options{Optimize: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(optimized.Removed) != test.removed {
+			t.Fatalf("tests[%d]: expected %d removed instructions, but got=%d", i, test.removed, len(optimized.Removed))
+		}
+
+		// remaining instructions assemble to the same words as without -O
+		plainWords := map[int]uint16{} // key: source line
+		for address, instrInfo := range plain.Instrs {
+			plainWords[instrInfo.AtLine] = plain.Words[address]
+		}
+		for address, instrInfo := range optimized.Instrs {
+			if word := optimized.Words[address]; word != plainWords[instrInfo.AtLine] {
+				t.Fatalf("tests[%d]: %v: expected=%d, but got=%d", i, instrInfo.Instr, plainWords[instrInfo.AtLine], word)
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package assembler
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/ishwar00/HackAssembler/errHandler"
+)
+
+const (
+	A_INSTRUCTION = 0
+	C_INSTRUCTION = 1
+	L_INSTRUCTION = 2
+)
+
+type InstructionInfo struct {
+	Instr    string // 16 bits
+	AtLine   int
+	AtColumn int // column of first character of Instr in source line, starts from 0
+	Type     int // A, L, C INSTRUCTION type
+	InFile   string
+	Macro    string // name of macro, if instruction is produced by its expansion
+}
+
+// returns where instruction is in source, for instruction produced by
+// macro expansion it is the macro invocation.
+func (instrInfo InstructionInfo) Site() SymbolSite {
+	return SymbolSite{File: instrInfo.InFile, Line: instrInfo.AtLine}
+}
+
+type Parser struct {
+	instrList  []InstructionInfo
+	nextInstr  int
+	totalInstr int
+}
+
+// takes preprocessed lines of program, removes comments and blank lines
+// and classifies each remaining line.
+func (p *Parser) Initialize(lines []SourceLine) {
+	*p = Parser{
+		instrList:  []InstructionInfo{},
+		nextInstr:  -1,
+		totalInstr: 0,
+	}
+
+	for _, sourceLine := range lines {
+		line := stripComment(sourceLine.Text)
+		column := len(line) - len(strings.TrimLeftFunc(line, unicode.IsSpace))
+		line = strings.TrimSpace(line)
+		if len(line) > 0 {
+			instrInfo := InstructionInfo{
+				Instr:    line,
+				AtLine:   sourceLine.AtLine,
+				AtColumn: column,
+				Type:     p.instrType(line),
+				InFile:   sourceLine.File,
+				Macro:    sourceLine.Macro,
+			}
+			if sourceLine.Macro != "" {
+				instrInfo.AtColumn = sourceLine.AtColumn
+			}
+			p.instrList = append(p.instrList, instrInfo)
+		}
+	}
+	p.totalInstr = len(p.instrList)
+}
+
+func (p Parser) HasMoreLines() bool {
+	return p.nextInstr+1 < p.totalInstr
+}
+
+func (p *Parser) Advance() {
+	p.nextInstr += 1
+}
+
+func (p Parser) InstructionType() int {
+	return p.instrList[p.nextInstr].Type
+}
+
+// mnemonics a C instruction written as comp alone is recognized by
+var bareComps = func() Code {
+	var code Code
+	code.Initialize()
+	return code
+}()
+
+func (p Parser) instrType(instruction string) int {
+	switch {
+	case instruction[0] == '(':
+		return L_INSTRUCTION
+	case instruction[0] == '@':
+		return A_INSTRUCTION
+	case strings.Contains(instruction, "=") || strings.Contains(instruction, ";"):
+		return C_INSTRUCTION
+	case bareComps.ExtendedComp(instruction) != "":
+		// comp alone, with neither dest nor jump, eg: 0
+		return C_INSTRUCTION
+	default:
+		return -1
+	}
+}
+
+func (p Parser) Symbol() string {
+	switch p.InstructionType() {
+	case L_INSTRUCTION:
+		instruction := p.instrList[p.nextInstr].Instr
+		return strings.Trim(instruction, "()") // (symbol) -> symbol
+	case A_INSTRUCTION:
+		instruction := p.instrList[p.nextInstr].Instr
+		return instruction[1:] // @vv...v -> vv...v
+	default:
+		return "" // does not contain any symbol
+	}
+}
+
+func (p Parser) Dest() string {
+	dest, _, _ := splitC(p.instrList[p.nextInstr].Instr)
+	return dest
+}
+
+func (p Parser) Comp() string {
+	_, comp, _ := splitC(p.instrList[p.nextInstr].Instr)
+	return comp
+}
+
+func (p Parser) Jump() string {
+	_, _, jump := splitC(p.instrList[p.nextInstr].Instr)
+	return jump
+}
+
+// splits C instruction dest=comp;jump into its fields, dest and jump
+// are empty when they are not specified.
+func splitC(instruction string) (dest, comp, jump string) {
+	if strings.Contains(instruction, "=") {
+		dest = strings.Split(instruction, "=")[0]
+		instruction = strings.SplitN(instruction, "=", 2)[1] // dest=comp;jump -> comp;jump
+	}
+	comp = strings.Split(instruction, ";")[0] // comp;jump
+	if strings.Contains(instruction, ";") {
+		jump = strings.Split(instruction, ";")[1]
+	}
+	return dest, comp, jump
+}
+
+func (p Parser) GetInstrInfo() InstructionInfo {
+	return p.instrList[p.nextInstr]
+}
+
+func (p Parser) GetTotalInstr() int {
+	return p.totalInstr
+}
+
+// prepares error pointing into current instruction, offset is index in
+// instruction where the faulty text begins and length is its length.
+// instruction produced by macro expansion is not in source, so error
+// points at the macro invocation instead.
+func (p Parser) ErrorAt(offset, length int, errMsg string) errhandler.Error {
+	return errorAt(p.GetInstrInfo(), offset, length, errMsg)
+}
+
+func errorAt(instrInfo InstructionInfo, offset, length int, errMsg string) errhandler.Error {
+	if instrInfo.Macro != "" {
+		offset, length = 0, len(instrInfo.Macro)
+		errMsg = fmt.Sprintf("%v\nin expansion of macro %v: %v", errMsg, instrInfo.Macro, instrInfo.Instr)
+	}
+	return errhandler.Error{
+		ErrMsg:   errMsg,
+		OnLine:   instrInfo.AtLine - 1,
+		OnColumn: instrInfo.AtColumn + offset,
+		Length:   length,
+		File:     instrInfo.InFile,
+	}
+}
@@ -0,0 +1,396 @@
+package assembler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fatih/color"
+	"github.com/ishwar00/HackAssembler/errHandler"
+)
+
+// Preprocessor runs in front of Parser and handles directives, which are not
+// part of Hack assembly language:
+//
+//	.macro NAME param1, param2 ...   defines macro NAME, body ends with .endm,
+//	                                 inside body \param1 is replaced by argument
+//	                                 and \@ by a number unique to each expansion
+//	.endm
+//	NAME arg1, arg2 ...              expands macro NAME
+//	.equ NAME value                  defines constant NAME, usable as @NAME,
+//	                                 value is decimal, hex 0x.. or binary 0b..
+//	.include "file.asm"              includes file.asm, path is relative to
+//	                                 directory of the including file
+//	.export NAME1, NAME2 ...         labels other modules may use, see AssembleObject
+//	.import NAME1, NAME2 ...         labels which must come from other modules
+type Preprocessor struct {
+	include    func(path string) (io.ReadCloser, error)
+	errs       *errhandler.ErrHandler
+	macros     map[string]*macro
+	Constants  []Constant
+	Exports    []Declaration
+	Imports    []Declaration
+	sources    map[string][]string // key: fileName, value: lines of file as read
+	including  []string            // files being processed, innermost is last
+	expanding  []string            // macros being expanded, innermost is last
+	expansions int                 // number of expansions so far, replaces \@
+	lines      []SourceLine
+}
+
+// SourceLine is a line of assembly produced by Preprocessor, with the position it came from
+type SourceLine struct {
+	Text   string
+	File   string
+	AtLine int // starts from 1
+
+	// set for lines produced by macro expansion, name of the macro and column
+	// at which it is invoked on line AtLine, Text is the expanded line
+	Macro    string
+	AtColumn int
+}
+
+// Constant defined by .equ directive
+type Constant struct {
+	Name  string
+	Value int
+	Line  SourceLine // .equ line
+}
+
+// Declaration of a symbol by .export or .import directive
+type Declaration struct {
+	Name string
+	Line SourceLine
+}
+
+type macro struct {
+	name   string
+	params []string
+	body   []string
+}
+
+// include opens files named by .include directive, if nil, they are opened from disk.
+// errors found while preprocessing are added to errs.
+func (pp *Preprocessor) Initialize(include func(path string) (io.ReadCloser, error), errs *errhandler.ErrHandler) {
+	if include == nil {
+		include = func(path string) (io.ReadCloser, error) {
+			return os.Open(path)
+		}
+	}
+	*pp = Preprocessor{
+		include:   include,
+		errs:      errs,
+		macros:    map[string]*macro{},
+		Constants: []Constant{},
+		Exports:   []Declaration{},
+		Imports:   []Declaration{},
+		sources:   map[string][]string{},
+		including: []string{},
+		expanding: []string{},
+		lines:     []SourceLine{},
+	}
+}
+
+// reads src and returns its lines with directives handled, included files
+// are spliced in and macros are expanded. returned error is only for failure
+// to read src, errors in source are added to errs given to Initialize.
+func (pp *Preprocessor) Process(src io.Reader, fileName string) ([]SourceLine, error) {
+	if err := pp.processFile(src, fileName); err != nil {
+		return nil, err
+	}
+	return pp.lines, nil
+}
+
+// returns every file read while preprocessing, key: fileName, value: lines of file.
+func (pp *Preprocessor) GetSources() map[string][]string {
+	return pp.sources
+}
+
+func (pp *Preprocessor) processFile(src io.Reader, fileName string) error {
+	lines, err := readLines(src)
+	if err != nil {
+		return err
+	}
+	pp.sources[fileName] = lines
+	pp.including = append(pp.including, filepath.Clean(fileName))
+	defer func() {
+		pp.including = pp.including[:len(pp.including)-1]
+	}()
+
+	var defining *macro // macro whose body is being read
+	var definedAt SourceLine
+	for i, text := range lines {
+		line := SourceLine{Text: text, File: fileName, AtLine: i + 1}
+		fields := strings.Fields(stripComment(text))
+
+		if defining != nil {
+			switch {
+			case len(fields) > 0 && fields[0] == ".endm":
+				pp.macros[defining.name] = defining
+				defining = nil
+			case len(fields) > 0 && fields[0] == ".macro":
+				pp.errorAt(line, fields[0], "macro definitions can not be nested, missing .endm?")
+			default:
+				defining.body = append(defining.body, text)
+			}
+			continue
+		}
+
+		if len(fields) > 0 && fields[0] == ".macro" {
+			defining = pp.defineMacro(line, fields)
+			definedAt = line
+			continue
+		}
+		pp.processLine(line)
+	}
+
+	if defining != nil {
+		pp.errorAt(definedAt, ".macro", fmt.Sprintf("macro %v is not closed with .endm", defining.name))
+		pp.macros[defining.name] = defining
+	}
+	return nil
+}
+
+// handles a line outside of macro definitions, line may come from
+// source file or from expansion of a macro.
+func (pp *Preprocessor) processLine(line SourceLine) {
+	code := stripComment(line.Text)
+	fields := strings.Fields(code)
+	if len(fields) == 0 {
+		pp.emit(line)
+		return
+	}
+
+	switch directive := fields[0]; {
+	case directive == ".equ":
+		pp.defineConstant(line, fields)
+	case directive == ".include":
+		pp.includeFile(line, code)
+	case directive == ".export":
+		pp.Exports = append(pp.Exports, pp.declare(line, code, directive)...)
+	case directive == ".import":
+		pp.Imports = append(pp.Imports, pp.declare(line, code, directive)...)
+	case directive == ".endm":
+		pp.errorAt(line, directive, ".endm without .macro")
+	case directive == ".macro":
+		pp.errorAt(line, directive, "macros can not be defined inside macros")
+	case strings.HasPrefix(directive, "."):
+		pp.errorAt(line, directive, fmt.Sprintf("unknown directive %v", color.RedString(directive)))
+	default:
+		if m, ok := pp.macros[directive]; ok {
+			args := splitArgs(strings.TrimSpace(code)[len(directive):])
+			pp.expand(m, args, line)
+			return
+		}
+		pp.emit(line)
+	}
+}
+
+// .macro NAME param1, param2 ...
+func (pp *Preprocessor) defineMacro(line SourceLine, fields []string) *macro {
+	code := strings.TrimSpace(stripComment(line.Text))
+	names := splitArgs(code[len(".macro"):])
+	if len(names) == 0 {
+		pp.errorAt(line, fields[0], "macro name is missing, expected .macro NAME params...")
+		return &macro{}
+	}
+	name, params := names[0], names[1:]
+	if !IsSymbol(name) {
+		pp.errorAt(line, name, fmt.Sprintf("invalid macro name %v", color.RedString(name)))
+	}
+	if _, ok := pp.macros[name]; ok {
+		pp.errorAt(line, name, fmt.Sprintf("macro %v is already defined", color.RedString(name)))
+	}
+
+	for i, param := range params {
+		if !IsSymbol(param) {
+			pp.errorAt(line, param, fmt.Sprintf("invalid macro parameter %v", color.RedString(param)))
+		}
+		for _, other := range params[:i] {
+			if other == param {
+				pp.errorAt(line, param, fmt.Sprintf("duplicate macro parameter %v", color.RedString(param)))
+			}
+		}
+	}
+	return &macro{name: name, params: params, body: []string{}}
+}
+
+// .equ NAME value
+func (pp *Preprocessor) defineConstant(line SourceLine, fields []string) {
+	if len(fields) != 3 {
+		pp.errorAt(line, fields[0], "malformed constant definition, expected .equ NAME value")
+		return
+	}
+	name, value := fields[1], fields[2]
+	if !IsSymbol(name) {
+		pp.errorAt(line, name, fmt.Sprintf("invalid constant name %v", color.RedString(name)))
+		return
+	}
+	v, err := parseLiteral(value)
+	if err != nil {
+		pp.errorAt(line, value, fmt.Sprintf("could not parse %v into integer", color.RedString(value)))
+		return
+	}
+	for _, constant := range pp.Constants {
+		if constant.Name == name {
+			pp.errorAt(line, name, fmt.Sprintf("constant %v is already defined", color.RedString(name)))
+			return
+		}
+	}
+	pp.Constants = append(pp.Constants, Constant{Name: name, Value: int(v), Line: line})
+}
+
+// .export NAME1, NAME2 ... or .import NAME1, NAME2 ...
+func (pp *Preprocessor) declare(line SourceLine, code string, directive string) []Declaration {
+	names := splitArgs(strings.TrimSpace(code)[len(directive):])
+	if len(names) == 0 {
+		pp.errorAt(line, directive, fmt.Sprintf("malformed %v, expected %v NAME1, NAME2 ...", directive, directive))
+	}
+	declarations := []Declaration{}
+	for _, name := range names {
+		if !IsSymbol(name) {
+			pp.errorAt(line, name, fmt.Sprintf("invalid symbol %v", color.RedString(name)))
+			continue
+		}
+		declarations = append(declarations, Declaration{Name: name, Line: line})
+	}
+	return declarations
+}
+
+// .include "file.asm"
+func (pp *Preprocessor) includeFile(line SourceLine, code string) {
+	arg := strings.TrimSpace(strings.TrimSpace(code)[len(".include"):])
+	if len(arg) < 2 || arg[0] != '"' || arg[len(arg)-1] != '"' {
+		pp.errorAt(line, ".include", "malformed include, expected .include \"file.asm\"")
+		return
+	}
+
+	path := arg[1 : len(arg)-1]
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(line.File), path)
+	}
+	for _, file := range pp.including {
+		if file == filepath.Clean(path) {
+			cycle := strings.Join(append(pp.including, file), " -> ")
+			pp.errorAt(line, arg, fmt.Sprintf("include cycle %v", color.RedString(cycle)))
+			return
+		}
+	}
+
+	src, err := pp.include(path)
+	if err != nil {
+		pp.errorAt(line, arg, fmt.Sprintf("could not include %v: %v", color.RedString(path), err))
+		return
+	}
+	defer src.Close()
+	if err := pp.processFile(src, path); err != nil {
+		pp.errorAt(line, arg, fmt.Sprintf("could not read %v: %v", color.RedString(path), err))
+	}
+}
+
+func (pp *Preprocessor) expand(m *macro, args []string, call SourceLine) {
+	if len(args) != len(m.params) {
+		errMsg := fmt.Sprintf("macro %v expects %d arguments, but got %d", color.RedString(m.name), len(m.params), len(args))
+		pp.errorAt(call, m.name, errMsg)
+		return
+	}
+	for _, name := range pp.expanding {
+		if name == m.name {
+			cycle := strings.Join(append(pp.expanding, m.name), " -> ")
+			pp.errorAt(call, m.name, fmt.Sprintf("recursive macro expansion %v", color.RedString(cycle)))
+			return
+		}
+	}
+	pp.expanding = append(pp.expanding, m.name)
+	defer func() {
+		pp.expanding = pp.expanding[:len(pp.expanding)-1]
+	}()
+
+	pp.expansions++
+	replacements := []string{`\@`, strconv.Itoa(pp.expansions)}
+	for i, param := range m.params {
+		replacements = append(replacements, `\`+param, args[i])
+	}
+	// longer parameters first, so \ab is not replaced as \a followed by b
+	replacer := strings.NewReplacer(sortByLength(replacements)...)
+
+	// expanded lines are attributed to the outermost invocation
+	origin := call
+	if origin.Macro == "" {
+		origin.Macro = m.name
+		origin.AtColumn = strings.Index(call.Text, m.name)
+	}
+	for _, text := range m.body {
+		line := origin
+		line.Text = replacer.Replace(text)
+		pp.processLine(line)
+	}
+}
+
+func (pp *Preprocessor) emit(line SourceLine) {
+	pp.lines = append(pp.lines, line)
+}
+
+// adds error pointing at first occurrence of text on line.
+func (pp *Preprocessor) errorAt(line SourceLine, text string, errMsg string) {
+	column, length := strings.Index(line.Text, text), len(text)
+	if line.Macro != "" {
+		column, length = line.AtColumn, len(line.Macro)
+		errMsg = fmt.Sprintf("%v\nin expansion of macro %v: %v", errMsg, line.Macro, strings.TrimSpace(line.Text))
+	}
+	pp.errs.Add(errhandler.Error{
+		ErrMsg:   errMsg,
+		OnLine:   line.AtLine - 1,
+		OnColumn: max(0, column),
+		Length:   length,
+		File:     line.File,
+	})
+}
+
+func readLines(src io.Reader) ([]string, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(src)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+func stripComment(line string) string {
+	if at := strings.Index(line, "//"); at != -1 {
+		return line[:at]
+	}
+	return line
+}
+
+// splits macro arguments or parameters, separated by commas and/or white space.
+func splitArgs(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
+
+// sorts old, new pairs of strings.NewReplacer by decreasing length of old.
+func sortByLength(pairs []string) []string {
+	sorted := append([]string{}, pairs...)
+	for i := 0; i < len(sorted); i += 2 {
+		for j := i + 2; j < len(sorted); j += 2 {
+			if len(sorted[j]) > len(sorted[i]) {
+				sorted[i], sorted[j] = sorted[j], sorted[i]
+				sorted[i+1], sorted[j+1] = sorted[j+1], sorted[i+1]
+			}
+		}
+	}
+	return sorted
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,143 @@
+package assembler
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ishwar00/HackAssembler/errHandler"
+)
+
+// opens files from files instead of disk
+func includeFrom(files map[string]string) func(path string) (io.ReadCloser, error) {
+	return func(path string) (io.ReadCloser, error) {
+		content, ok := files[path]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return io.NopCloser(strings.NewReader(content)), nil
+	}
+}
+
+func assembleWords(t *testing.T, input string, opts Options) []uint16 {
+	program, err := AssembleWith(strings.NewReader(input), "main.asm", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return program.Words
+}
+
+func TestMacros(t *testing.T) {
+	input := `
+.macro PUSHD
+	@SP
+	A=M
+	M=D
+	@SP
+	M=M+1
+.endm
+
+.macro JUMP_IF_ZERO value, target
+	@\value
+	D=M
+	@\target
+	D;JEQ
+.endm
+
+.macro SKIP
+	@SKIP\@
+	0;JMP
+(SKIP\@)
+.endm
+
+	PUSHD
+	JUMP_IF_ZERO R1 END
+	SKIP
+	SKIP
+(END)
+`
+	expected := `
+	@SP
+	A=M
+	M=D
+	@SP
+	M=M+1
+	@R1
+	D=M
+	@END
+	D;JEQ
+	@SKIP1
+	0;JMP
+(SKIP1)
+	@SKIP2
+	0;JMP
+(SKIP2)
+(END)
+`
+	got := assembleWords(t, input, Options{})
+	want := assembleWords(t, expected, Options{})
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("expected=%v, but got=%v", want, got)
+	}
+}
+
+func TestConstantsAndIncludes(t *testing.T) {
+	files := map[string]string{
+		"lib/consts.asm": ".equ SIZE 32\n.include \"macros.asm\"\n",
+		"lib/macros.asm": ".macro LOAD name\n@\\name\nD=A\n.endm\n",
+	}
+	input := ".include \"lib/consts.asm\"\nLOAD SIZE\n@COUNT\n.equ COUNT 7\n@i\n"
+	expected := []uint16{32, 0b1110110000010000, 7, 16}
+
+	got := assembleWords(t, input, Options{Include: includeFrom(files)})
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Fatalf("expected=%v, but got=%v", expected, got)
+	}
+
+	program, _ := AssembleWith(strings.NewReader(input), "main.asm", Options{Include: includeFrom(files)})
+	if program.Symbols.Kind("SIZE") != CONSTANT_SYMBOL {
+		t.Fatalf("expected SIZE to be a constant")
+	}
+	if program.Instrs[0].InFile != "main.asm" || program.Instrs[0].Macro != "LOAD" {
+		t.Fatalf("expected first instruction to come from LOAD in main.asm, but got=%+v", program.Instrs[0])
+	}
+}
+
+func TestPreprocessorErrors(t *testing.T) {
+	files := map[string]string{
+		"a.asm":    ".include \"b.asm\"\n",
+		"b.asm":    ".include \"a.asm\"\n",
+		"typo.asm": "@1\nD=X\n",
+	}
+	tests := []struct {
+		input       string
+		expectedOut []string
+	}{
+		{".include \"a.asm\"", []string{"b.asm:1:10: ", "include cycle"}},
+		{".include \"missing.asm\"", []string{"main.asm:1:10: ", "could not include"}},
+		{".include \"typo.asm\"", []string{"typo.asm:2:3: ", "unknown comp"}},
+		{".macro M\n@1", []string{"main.asm:1:1: ", "not closed with .endm"}},
+		{".endm", []string{"main.asm:1:1: ", ".endm without .macro"}},
+		{".macro A\nB\n.endm\n.macro B\nA\n.endm\nA", []string{"main.asm:7:1: ", "recursive macro expansion A -> B -> A"}},
+		{".macro M x\n@\\x\n.endm\n  M", []string{"main.asm:4:3: ", "expects 1 arguments"}},
+		{".macro M x\nD=\\x\n.endm\n  M Q", []string{"main.asm:4:3: ", "unknown comp", "in expansion of macro M: D=Q"}},
+		{".equ SP 3", []string{"main.asm:1:6: ", "predefined symbol"}},
+		{".equ X 3\n.equ X 4", []string{"main.asm:2:6: ", "already defined"}},
+		{".equ X y", []string{"main.asm:1:8: ", "could not parse"}},
+		{".org 3", []string{"main.asm:1:1: ", "unknown directive"}},
+	}
+
+	for i, tt := range tests {
+		_, err := AssembleWith(strings.NewReader(tt.input), "main.asm", Options{Include: includeFrom(files)})
+		if _, ok := err.(*errhandler.ErrHandler); !ok {
+			t.Fatalf("tests[%d]: expected *errhandler.ErrHandler, but got=%v", i, err)
+		}
+		for _, out := range tt.expectedOut {
+			if !strings.Contains(err.Error(), out) {
+				t.Fatalf("tests[%d]: expected report to contain %q, but got=%v", i, out, err)
+			}
+		}
+	}
+}
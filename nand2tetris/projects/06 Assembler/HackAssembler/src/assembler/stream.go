@@ -0,0 +1,153 @@
+package assembler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/fatih/color"
+	"github.com/ishwar00/HackAssembler/errHandler"
+)
+
+// A instruction whose operand refers to symbols not known yet when it was
+// read, its word is patched once all labels are declared
+type fixup struct {
+	address   int
+	instrInfo InstructionInfo
+}
+
+// AssembleStream translates Hack assembly read from src into machine code in
+// a single pass, it is meant for large generated programs, eg: Pong with the
+// OS translated by VMTranslator. Each line is read once and assembled right
+// away. Labels declared earlier are known, an A instruction referring to a
+// symbol not known yet gets a placeholder word and is patched at the end, when
+// every label is declared and remaining symbols are allocated as variables,
+// in order of their first use, as Assemble does.
+//
+// Source lines are not kept, so errors are reported without context lines,
+// Program.Instrs and Program.Source are not filled, so WriteListing can not
+// list the program, and directives of Preprocessor are not supported. opts.Include and opts.Werror are ignored,
+// opts.Optimize needs the whole program and is an error.
+func AssembleStream(src io.Reader, name string, opts Options) (Program, error) {
+	if opts.Optimize {
+		return Program{}, fmt.Errorf("%v: optimizer needs the whole program, it can not be used while streaming", name)
+	}
+
+	var code Code
+	var st SymbolTable
+	var errs errhandler.ErrHandler
+	target := opts.target()
+	code.Initialize()
+	st.InitializeWith(target)
+	program := Program{Name: name, Words: []uint16{}}
+	fixups := []fixup{}
+	var beyondROM *InstructionInfo // first instruction which does not fit in ROM
+
+	scanner := bufio.NewScanner(src)
+	for atLine := 1; scanner.Scan(); atLine++ {
+		line := stripComment(scanner.Text())
+		instr := strings.TrimSpace(line)
+		if len(instr) == 0 {
+			continue
+		}
+		instrInfo := InstructionInfo{
+			Instr:    instr,
+			AtLine:   atLine,
+			AtColumn: len(line) - len(strings.TrimLeftFunc(line, unicode.IsSpace)),
+			Type:     Parser{}.instrType(instr),
+			InFile:   name,
+		}
+
+		if len(program.Words) == target.ROMSize && beyondROM == nil && instrInfo.Type != L_INSTRUCTION {
+			beyondROM = &instrInfo
+		}
+
+		switch instrInfo.Type {
+		case L_INSTRUCTION:
+			symbol := strings.Trim(instr, "()")
+			if !strings.HasSuffix(instr, ")") || !IsSymbol(symbol) {
+				errMsg := fmt.Sprintf("malformed label declaration %v, expected (symbol)", color.RedString(instr))
+				errs.Add(errorAt(instrInfo, 0, len(instr), errMsg))
+				continue
+			}
+			if st.Contains(symbol) {
+				errMsg := st.labelConflict(symbol)
+				errs.Add(errorAt(instrInfo, 1, len(symbol), errMsg))
+				continue
+			}
+			st.AddEntry(symbol, len(program.Words), LABEL_SYMBOL)
+			st.SetDefinedAt(symbol, instrInfo.Site())
+
+		case A_INSTRUCTION:
+			known := true
+			_, err := sumOperand(instr[1:], func(symbol string) int {
+				known = known && st.Contains(symbol)
+				st.MarkUse(symbol, instrInfo.Site())
+				return 0
+			})
+			if err == nil && !known {
+				fixups = append(fixups, fixup{len(program.Words), instrInfo})
+				program.Words = append(program.Words, 0)
+				continue
+			}
+			value, err := evaluateOperand(instr[1:], st.GetAddress)
+			if err != nil {
+				e := err.(*operandError)
+				errs.Add(errorAt(instrInfo, 1+e.offset, e.length, e.errMsg))
+				continue
+			}
+			program.Words = append(program.Words, uint16(value))
+
+		case C_INSTRUCTION:
+			word, cErrs := encodeC(code, instr, opts)
+			for _, e := range cErrs {
+				errs.Add(errorAt(instrInfo, e.offset, e.length, e.errMsg))
+			}
+			if len(cErrs) == 0 {
+				program.Words = append(program.Words, word)
+			}
+
+		default:
+			errMsg := "alien instruction: failed to classify the instruction"
+			if strings.HasPrefix(instr, ".") {
+				errMsg = "directives are not supported while streaming, assemble without streaming"
+			}
+			errs.Add(errorAt(instrInfo, 0, len(instr), errMsg))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Program{}, fmt.Errorf("%v: %w", name, err)
+	}
+
+	// every label is declared now, remaining symbols are variables
+	for _, f := range fixups {
+		operand := f.instrInfo.Instr[1:]
+		resolve := func(symbol string) int {
+			if !st.Contains(symbol) {
+				site, _ := st.FirstUse(symbol)
+				if err := st.AddVariable(symbol, site); err != nil {
+					errs.Add(errorAt(f.instrInfo, 1+strings.Index(operand, symbol), len(symbol), err.Error()))
+				}
+			}
+			return st.GetAddress(symbol)
+		}
+		value, err := evaluateOperand(operand, resolve)
+		if err != nil {
+			e := err.(*operandError)
+			errs.Add(errorAt(f.instrInfo, 1+e.offset, e.length, e.errMsg))
+			continue
+		}
+		program.Words[f.address] = uint16(value)
+	}
+
+	if beyondROM != nil && len(program.Words) > target.ROMSize {
+		errs.Add(romOverflow(*beyondROM, len(program.Words), target))
+	}
+	if errs.Error_count() > 0 {
+		return Program{}, &errs
+	}
+	program.Symbols = st
+	return program, nil
+}
@@ -0,0 +1,132 @@
+package assembler
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ishwar00/HackAssembler/errHandler"
+)
+
+var streamFiles = []string{
+	"../../../add/Add.asm",
+	"../../../max/Max.asm",
+	"../../../rect/Rect.asm",
+	"../../../pong/Pong.asm",
+}
+
+func TestAssembleStream(t *testing.T) {
+	inputs := []string{
+		// forward references, variables allocated in order of first use
+		"@i\n@END\n0;JMP\n@j\n@i\n@LOOP+1\n(LOOP)\n@END-1\n(END)\n@k\n",
+		"@0x10+x\nD=A\n@SCREEN+32\n(x)\n@x-1\n",
+	}
+	for _, asmPath := range streamFiles {
+		source, err := os.ReadFile(asmPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, string(source))
+	}
+
+	for i, input := range inputs {
+		expected, err := Assemble(strings.NewReader(input), "test.asm")
+		if err != nil {
+			t.Fatal(err)
+		}
+		program, err := AssembleStream(strings.NewReader(input), "test.asm", Options{})
+		if err != nil {
+			t.Fatalf("inputs[%d]: %v", i, err)
+		}
+		if fmt.Sprint(program.Words) != fmt.Sprint(expected.Words) {
+			t.Fatalf("inputs[%d]: expected=%v, but got=%v", i, expected.Words, program.Words)
+		}
+		for _, variable := range expected.Symbols.Symbols(VARIABLE_SYMBOL) {
+			if program.Symbols.GetAddress(variable) != expected.Symbols.GetAddress(variable) {
+				t.Fatalf("inputs[%d]: expected %v at %d, but got=%d", i, variable,
+					expected.Symbols.GetAddress(variable), program.Symbols.GetAddress(variable))
+			}
+		}
+	}
+}
+
+func TestAssembleStreamErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedCount int
+		expectedOut   []string
+	}{
+		{"D=X", 1, []string{"test.asm:1:3: ", "unknown comp"}},
+		{"(LOOP)\n(LOOP)", 1, []string{"test.asm:2:2: ", "duplicate label"}},
+		{"(SCREEN)", 1, []string{"test.asm:1:2: ", "label SCREEN conflicts with predefined symbol"}},
+		{"@later-2\n(later)", 1, []string{"test.asm:1:2: ", "does not fit in 15 bits"}},
+		{"@x+0xZZ\n", 1, []string{"test.asm:1:4: ", "could not parse"}},
+		{".equ N 3\n", 1, []string{"test.asm:1:1: ", "directives are not supported"}},
+		{"@x\nD=Q\n@1y\n(x)\nfoo\n(x)\n0;JMP", 4, []string{
+			"test.asm:2:3: ", "test.asm:3:2: ", "test.asm:5:1: ", "test.asm:6:2: ",
+		}},
+	}
+
+	for i, tt := range tests {
+		_, err := AssembleStream(strings.NewReader(tt.input), "test.asm", Options{})
+		errs, ok := err.(*errhandler.ErrHandler)
+		if !ok {
+			t.Fatalf("tests[%d]: expected *errhandler.ErrHandler, but got=%T", i, err)
+		}
+		if errs.Error_count() != tt.expectedCount {
+			t.Fatalf("tests[%d]: expected %d errors, but got=%d\n%v", i, tt.expectedCount, errs.Error_count(), err)
+		}
+		for _, out := range tt.expectedOut {
+			if !strings.Contains(err.Error(), out) {
+				t.Fatalf("tests[%d]: expected report to contain %q, but got=%v", i, out, err)
+			}
+		}
+	}
+
+	if _, err := AssembleStream(strings.NewReader("@0"), "test.asm", Options{Optimize: true}); err == nil {
+		t.Fatalf("expected optimizer to be refused while streaming")
+	}
+}
+
+// go test -bench Assemble -benchmem ./assembler
+func BenchmarkAssemble(b *testing.B) {
+	source, err := os.ReadFile("../../../pong/Pong.asm")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.SetBytes(int64(len(source)))
+	for i := 0; i < b.N; i++ {
+		if _, err := Assemble(bytes.NewReader(source), "Pong.asm"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAssembleStream(b *testing.B) {
+	source, err := os.ReadFile("../../../pong/Pong.asm")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.SetBytes(int64(len(source)))
+	for i := 0; i < b.N; i++ {
+		if _, err := AssembleStream(bytes.NewReader(source), "Pong.asm", Options{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestStreamListing(t *testing.T) {
+	program, err := AssembleStream(strings.NewReader("@i\nM=0\n"), "test.asm", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listing bytes.Buffer
+	err = WriteListing(&listing, program)
+	if err == nil || !strings.Contains(err.Error(), "listing of test.asm needs its instructions") {
+		t.Fatalf("expected error of missing instructions, but got=%v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package assembler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/ishwar00/HackAssembler/errHandler"
+)
+
+// kinds of symbols, a symbol is either predefined by the platform, declared as
+// a label (xxx), allocated as a variable by @xxx or defined by .equ directive
+const (
+	PREDEFINED_SYMBOL = 0
+	LABEL_SYMBOL      = 1
+	VARIABLE_SYMBOL   = 2
+	CONSTANT_SYMBOL   = 3
+)
+
+type SymbolTable struct {
+	ST        map[string]int
+	kind      map[string]int
+	definedAt map[string]SymbolSite
+	firstUse  map[string]SymbolSite
+
+	// RAM addresses of variables, next one is allocated at nextVariable
+	nextVariable  int
+	variableBase  int
+	variableLimit int
+}
+
+// SymbolSite is the place in source where a symbol appears.
+type SymbolSite struct {
+	File string `json:"file"`
+	Line int    `json:"line"` // starts from 1
+}
+
+// initializes symbol table for DefaultTarget.
+func (st *SymbolTable) Initialize() {
+	st.InitializeWith(DefaultTarget())
+}
+
+// initializes symbol table with predefined symbols of target,
+// variables are allocated in RAM of target given for them.
+func (st *SymbolTable) InitializeWith(target Target) {
+	*st = SymbolTable{
+		ST:            map[string]int{},
+		kind:          map[string]int{},
+		definedAt:     map[string]SymbolSite{},
+		firstUse:      map[string]SymbolSite{},
+		nextVariable:  target.VariableBase,
+		variableBase:  target.VariableBase,
+		variableLimit: target.VariableLimit,
+	}
+	for symbol, address := range target.Symbols {
+		st.AddEntry(symbol, address, PREDEFINED_SYMBOL)
+	}
+}
+
+func (st *SymbolTable) AddEntry(symbol string, address int, kind int) {
+	st.ST[symbol] = address
+	st.kind[symbol] = kind
+}
+
+// adds symbol as variable at next free RAM address, it is defined at site.
+// returns error if there is no RAM left for variables.
+func (st *SymbolTable) AddVariable(symbol string, site SymbolSite) error {
+	address := st.nextVariable
+	st.nextVariable++
+	st.AddEntry(symbol, address, VARIABLE_SYMBOL)
+	st.SetDefinedAt(symbol, site)
+	if address > st.variableLimit {
+		return fmt.Errorf("no RAM left for variable %v, variables may use RAM[%d] to RAM[%d]",
+			color.RedString(symbol), st.variableBase, st.variableLimit)
+	}
+	return nil
+}
+
+// records where symbol is declared, a label by (xxx), a constant by .equ and
+// a variable where it is used first.
+func (st *SymbolTable) SetDefinedAt(symbol string, site SymbolSite) {
+	st.definedAt[symbol] = site
+}
+
+// records use of symbol by A instruction, only the first use is kept.
+func (st *SymbolTable) MarkUse(symbol string, site SymbolSite) {
+	if _, ok := st.firstUse[symbol]; !ok {
+		st.firstUse[symbol] = site
+	}
+}
+
+// returns where symbol is declared, false for predefined symbols.
+func (st SymbolTable) DefinedAt(symbol string) (SymbolSite, bool) {
+	site, ok := st.definedAt[symbol]
+	return site, ok
+}
+
+// returns where symbol is used first, false if it is never used.
+func (st SymbolTable) FirstUse(symbol string) (SymbolSite, bool) {
+	site, ok := st.firstUse[symbol]
+	return site, ok
+}
+
+func (st SymbolTable) Kind(symbol string) int {
+	return st.kind[symbol]
+}
+
+// returns symbols of given kind ordered by address, symbols sharing
+// an address are ordered by name.
+func (st SymbolTable) Symbols(kind int) []string {
+	symbols := []string{}
+	for symbol, k := range st.kind {
+		if k == kind {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		if st.ST[symbols[i]] == st.ST[symbols[j]] {
+			return symbols[i] < symbols[j]
+		}
+		return st.ST[symbols[i]] < st.ST[symbols[j]]
+	})
+	return symbols
+}
+
+func (st SymbolTable) Contains(symbol string) bool {
+	_, ok := st.ST[symbol]
+	return ok
+}
+
+func (st SymbolTable) GetAddress(symbol string) int {
+	return st.ST[symbol]
+}
+
+// returns why label symbol can't be declared, symbol is already in st.
+func (st SymbolTable) labelConflict(symbol string) string {
+	switch st.Kind(symbol) {
+	case PREDEFINED_SYMBOL:
+		return fmt.Sprintf("label %v conflicts with predefined symbol", color.RedString(symbol))
+	case CONSTANT_SYMBOL:
+		return fmt.Sprintf("label %v conflicts with constant defined by .equ", color.RedString(symbol))
+	}
+	return fmt.Sprintf("duplicate label %v found, labels must be unique", color.RedString(symbol))
+}
+
+// records address of every label declaration (xxx) in program, malformed labels
+// and labels naming a symbol already in st are added to errs and pass continues
+// with next instruction.
+// parser is taken by value, so caller's parser is not advanced.
+func (st *SymbolTable) DoPass1(parser Parser, errs *errhandler.ErrHandler) {
+	address := -1
+	for parser.HasMoreLines() {
+		parser.Advance()
+		if parser.InstructionType() == L_INSTRUCTION {
+			instr := parser.GetInstrInfo().Instr
+			symbol := parser.Symbol()
+			if !strings.HasSuffix(instr, ")") || !IsSymbol(symbol) {
+				errMsg := fmt.Sprintf("malformed label declaration %v, expected (symbol)", color.RedString(instr))
+				errs.Add(parser.ErrorAt(0, len(instr), errMsg))
+				continue
+			}
+			if st.Contains(symbol) {
+				errMsg := st.labelConflict(symbol)
+				errs.Add(parser.ErrorAt(1, len(symbol), errMsg))
+				continue
+			}
+			st.AddEntry(symbol, address+1, LABEL_SYMBOL)
+			st.SetDefinedAt(symbol, parser.GetInstrInfo().Site())
+			continue
+		}
+		address++
+	}
+}
@@ -0,0 +1,150 @@
+package assembler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// symbol table of assembled program can be exported, so that debuggers,
+// emulators and profilers show names instead of raw addresses. It is written
+// either as JSON:
+//
+//	{"program": "Max.asm", "symbols": [
+//	  {"name": "OUTPUT_FIRST", "kind": "label", "address": 10,
+//	   "defined": {"file": "Max.asm", "line": 19}, "firstUse": {"file": "Max.asm", "line": 13}},
+//	  ...]}
+//
+// or as .sym text, one symbol per line with tab separated fields
+// kind, address, name, definition and first use as file:line, - if missing:
+//
+//	label	10	OUTPUT_FIRST	Max.asm:19	Max.asm:13
+
+// Symbol is an entry of exported symbol table. Address is ROM address of
+// a label, RAM address of a variable or predefined symbol and value of a constant.
+type Symbol struct {
+	Name     string      `json:"name"`
+	Kind     string      `json:"kind"`
+	Address  int         `json:"address"`
+	Defined  *SymbolSite `json:"defined,omitempty"`
+	FirstUse *SymbolSite `json:"firstUse,omitempty"`
+}
+
+// names of symbol kinds in exported symbol table
+var kindNames = map[int]string{
+	LABEL_SYMBOL:      "label",
+	VARIABLE_SYMBOL:   "variable",
+	CONSTANT_SYMBOL:   "constant",
+	PREDEFINED_SYMBOL: "predefined",
+}
+
+// returns symbols of program, labels, variables, constants and predefined
+// symbols, each kind ordered by address.
+func ExportSymbols(program Program) []Symbol {
+	symbols := []Symbol{}
+	for _, kind := range []int{LABEL_SYMBOL, VARIABLE_SYMBOL, CONSTANT_SYMBOL, PREDEFINED_SYMBOL} {
+		for _, name := range program.Symbols.Symbols(kind) {
+			symbol := Symbol{Name: name, Kind: kindNames[kind], Address: program.Symbols.GetAddress(name)}
+			if site, ok := program.Symbols.DefinedAt(name); ok {
+				symbol.Defined = &site
+			}
+			if site, ok := program.Symbols.FirstUse(name); ok {
+				symbol.FirstUse = &site
+			}
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols
+}
+
+// writes symbol table of program as JSON.
+func WriteSymbolsJSON(w io.Writer, program Program) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Program string   `json:"program"`
+		Symbols []Symbol `json:"symbols"`
+	}{program.Name, ExportSymbols(program)})
+}
+
+// writes symbol table of program as .sym text.
+func WriteSymbols(w io.Writer, program Program) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "// symbols of %s\n", program.Name)
+	fmt.Fprintf(bw, "// kind\taddress\tname\tdefined\tfirst use\n")
+	for _, symbol := range ExportSymbols(program) {
+		fmt.Fprintf(bw, "%s\t%d\t%s\t%s\t%s\n", symbol.Kind, symbol.Address, symbol.Name,
+			formatSite(symbol.Defined), formatSite(symbol.FirstUse))
+	}
+	return bw.Flush()
+}
+
+// reads symbol table written by WriteSymbolsJSON or WriteSymbols,
+// format is known from content.
+func ReadSymbols(r io.Reader) ([]Symbol, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		var table struct {
+			Symbols []Symbol `json:"symbols"`
+		}
+		if err := json.Unmarshal(raw, &table); err != nil {
+			return nil, err
+		}
+		return table.Symbols, nil
+	}
+
+	symbols := []Symbol{}
+	for i, line := range strings.Split(string(raw), "\n") {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		fields := strings.Split(strings.TrimRight(line, "\r"), "\t")
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("line %d: expected 5 tab separated fields, but got %d", i+1, len(fields))
+		}
+		address, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: malformed address %q", i+1, fields[1])
+		}
+		symbol := Symbol{Kind: fields[0], Address: address, Name: fields[2]}
+		if symbol.Defined, err = parseSite(fields[3]); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		if symbol.FirstUse, err = parseSite(fields[4]); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, nil
+}
+
+func formatSite(site *SymbolSite) string {
+	if site == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%s:%d", site.File, site.Line)
+}
+
+// file:line, file itself may contain :
+func parseSite(s string) (*SymbolSite, error) {
+	if s == "-" {
+		return nil, nil
+	}
+	at := strings.LastIndex(s, ":")
+	if at == -1 {
+		return nil, fmt.Errorf("malformed site %q, expected file:line", s)
+	}
+	line, err := strconv.Atoi(s[at+1:])
+	if err != nil {
+		return nil, fmt.Errorf("malformed line in site %q", s)
+	}
+	return &SymbolSite{File: s[:at], Line: line}, nil
+}
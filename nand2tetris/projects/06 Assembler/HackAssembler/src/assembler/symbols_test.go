@@ -0,0 +1,99 @@
+package assembler
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportSymbols(t *testing.T) {
+	input := `.equ SIZE 32
+	@i
+	M=0
+(LOOP)
+	@i
+	D=M
+	@SIZE
+	D=D-A
+	@END
+	D;JGE
+	@LOOP
+	0;JMP
+(END)
+	@END
+	0;JMP
+`
+	expected := []string{
+		"label 2 LOOP test.asm:4 test.asm:11",
+		"label 10 END test.asm:13 test.asm:9",
+		"variable 16 i test.asm:2 test.asm:2",
+		"constant 32 SIZE test.asm:1 test.asm:7",
+	}
+
+	program, err := Assemble(strings.NewReader(input), "test.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	symbols := ExportSymbols(program)
+	for i, line := range expected {
+		symbol := symbols[i]
+		got := fmt.Sprintf("%v %v %v %v %v", symbol.Kind, symbol.Address, symbol.Name,
+			formatSite(symbol.Defined), formatSite(symbol.FirstUse))
+		if got != line {
+			t.Fatalf("symbols[%d]: expected=%q, but got=%q", i, line, got)
+		}
+	}
+	if predefined := symbols[len(expected)]; predefined.Kind != "predefined" || predefined.Defined != nil {
+		t.Fatalf("expected predefined symbols after constants, but got=%+v", predefined)
+	}
+
+	// streaming assembler records the same sites
+	source := strings.SplitN(input, "\n", 2)[1]
+	source = strings.ReplaceAll(source, "@SIZE", "@32")
+	streamed, err := AssembleStream(strings.NewReader(source), "test.asm", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"LOOP", "END", "i"} {
+		defined, _ := streamed.Symbols.DefinedAt(name)
+		firstUse, _ := streamed.Symbols.FirstUse(name)
+		expectedDefined, _ := program.Symbols.DefinedAt(name)
+		expectedFirstUse, _ := program.Symbols.FirstUse(name)
+		// source of streamed program starts one line later
+		if defined.Line != expectedDefined.Line-1 || firstUse.Line != expectedFirstUse.Line-1 {
+			t.Fatalf("%v: expected defined=%v, first use=%v, but got=%v, %v",
+				name, expectedDefined, expectedFirstUse, defined, firstUse)
+		}
+	}
+}
+
+func TestReadSymbols(t *testing.T) {
+	program, err := Assemble(strings.NewReader("@x\n(LOOP)\n@LOOP\n0;JMP\n"), "dir with space/test.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ExportSymbols(program)
+
+	for _, write := range []func(w *bytes.Buffer) error{
+		func(w *bytes.Buffer) error { return WriteSymbols(w, program) },
+		func(w *bytes.Buffer) error { return WriteSymbolsJSON(w, program) },
+	} {
+		var buf bytes.Buffer
+		if err := write(&buf); err != nil {
+			t.Fatal(err)
+		}
+		symbols, err := ReadSymbols(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(symbols, expected) {
+			t.Fatalf("expected=%+v, but got=%+v", expected, symbols)
+		}
+	}
+
+	if _, err := ReadSymbols(strings.NewReader("label\tx\tLOOP\t-\t-\n")); err == nil {
+		t.Fatalf("expected error for malformed address")
+	}
+}
@@ -0,0 +1,107 @@
+package assembler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Target describes the Hack machine programs are assembled for, its
+// predefined symbols, RAM used for variables and size of ROM.
+type Target struct {
+	Symbols       map[string]int // predefined symbols, key: symbol, value: address
+	VariableBase  int            // RAM address of first variable
+	VariableLimit int            // last RAM address a variable may use
+	ROMSize       int            // number of instructions ROM holds
+}
+
+// largest ROM Hack can address, A instruction loads at most 15 bits
+const maxROMSize = 1 << 15
+
+// returns standard Hack computer of the course, R0-R15 with SP, LCL, ARG, THIS
+// and THAT, SCREEN and KBD, variables from RAM[16] up to the screen memory
+// map and 32K ROM.
+func DefaultTarget() Target {
+	symbols := map[string]int{
+		"SP":     0,
+		"LCL":    1,
+		"ARG":    2,
+		"THIS":   3,
+		"THAT":   4,
+		"SCREEN": 16384,
+		"KBD":    24576,
+	}
+	for i := 0; i < 16; i++ {
+		symbols[fmt.Sprintf("R%d", i)] = i
+	}
+	return Target{
+		Symbols:       symbols,
+		VariableBase:  16,
+		VariableLimit: 16383,
+		ROMSize:       maxROMSize,
+	}
+}
+
+// ReadTarget reads target description, it starts from DefaultTarget and
+// changes it line by line, // starts a comment:
+//
+//	// Hack with 8K ROM and a LED at RAM[24577]
+//	rom 8192
+//	variables 16 255        // first and last RAM address of variables
+//	symbol LED 24577        // adds or redefines predefined symbol
+//	nosymbols               // drops predefined symbols defined so far
+func ReadTarget(r io.Reader) (Target, error) {
+	target := DefaultTarget()
+	scanner := bufio.NewScanner(r)
+	for atLine := 1; scanner.Scan(); atLine++ {
+		fields := strings.Fields(stripComment(scanner.Text()))
+		if len(fields) == 0 {
+			continue
+		}
+
+		values := []int{}
+		for _, field := range fields[1:] {
+			if v, err := parseLiteral(field); err == nil {
+				values = append(values, int(v))
+			}
+		}
+
+		var err error
+		switch key := fields[0]; {
+		case key == "rom" && len(fields) == 2 && len(values) == 1:
+			target.ROMSize = values[0]
+			if target.ROMSize < 1 || target.ROMSize > maxROMSize {
+				err = fmt.Errorf("rom size %d is not in 1 to %d", target.ROMSize, maxROMSize)
+			}
+		case key == "variables" && len(fields) == 3 && len(values) == 2:
+			target.VariableBase, target.VariableLimit = values[0], values[1]
+			if target.VariableBase < 0 || target.VariableLimit > maxAValue || target.VariableBase > target.VariableLimit+1 {
+				err = fmt.Errorf("variables %d to %d are not in RAM 0 to %d", values[0], values[1], maxAValue)
+			}
+		case key == "symbol" && len(fields) == 3 && len(values) == 1:
+			if !IsSymbol(fields[1]) {
+				err = fmt.Errorf("%v is not a symbol", fields[1])
+			} else if values[0] < 0 || values[0] > maxAValue {
+				err = fmt.Errorf("address %d of %v is not in 0 to %d", values[0], fields[1], maxAValue)
+			}
+			target.Symbols[fields[1]] = values[0]
+		case key == "nosymbols" && len(fields) == 1:
+			target.Symbols = map[string]int{}
+		default:
+			err = fmt.Errorf("expected rom SIZE, variables BASE LIMIT, symbol NAME ADDRESS or nosymbols")
+		}
+		if err != nil {
+			return Target{}, fmt.Errorf("line %d: %w", atLine, err)
+		}
+	}
+	return target, scanner.Err()
+}
+
+// returns target of opts, DefaultTarget if it is not given.
+func (opts Options) target() Target {
+	if opts.Target != nil {
+		return *opts.Target
+	}
+	return DefaultTarget()
+}
@@ -0,0 +1,91 @@
+package assembler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReadTarget(t *testing.T) {
+	description := `
+// Hack with 8K ROM and a LED
+rom 0x2000
+variables 16 17   // two variables only
+symbol LED 24577
+symbol SCREEN 0x5000
+`
+	target, err := ReadTarget(strings.NewReader(description))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.ROMSize != 8192 || target.VariableBase != 16 || target.VariableLimit != 17 {
+		t.Fatalf("expected rom 8192, variables 16 to 17, but got=%+v", target)
+	}
+	if target.Symbols["LED"] != 24577 || target.Symbols["SCREEN"] != 0x5000 || target.Symbols["R15"] != 15 {
+		t.Fatalf("expected LED, redefined SCREEN and default R15, but got=%v", target.Symbols)
+	}
+
+	target, err = ReadTarget(strings.NewReader("nosymbols\nsymbol IO 100\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(target.Symbols) != 1 || target.Symbols["IO"] != 100 {
+		t.Fatalf("expected IO only, but got=%v", target.Symbols)
+	}
+
+	tests := []struct {
+		description string
+		expectedErr string
+	}{
+		{"rom 40000", "line 1: rom size 40000 is not in 1 to 32768"},
+		{"\nvariables 16", "line 2: expected rom SIZE"},
+		{"variables 100 10", "line 1: variables 100 to 10 are not in RAM"},
+		{"symbol 1x 3", "line 1: 1x is not a symbol"},
+		{"symbol BIG 40000", "line 1: address 40000 of BIG is not in 0 to 32767"},
+		{"ram 10", "line 1: expected rom SIZE"},
+	}
+	for i, tt := range tests {
+		_, err := ReadTarget(strings.NewReader(tt.description))
+		if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+			t.Fatalf("tests[%d]: expected error %q, but got=%v", i, tt.expectedErr, err)
+		}
+	}
+}
+
+func TestAssembleForTarget(t *testing.T) {
+	target, err := ReadTarget(strings.NewReader("rom 4\nvariables 100 101\nsymbol LED 24577\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{Target: &target}
+	assemblers := map[string]func(input string) (Program, error){
+		"Assemble": func(input string) (Program, error) {
+			return AssembleWith(strings.NewReader(input), "test.asm", opts)
+		},
+		"AssembleStream": func(input string) (Program, error) {
+			return AssembleStream(strings.NewReader(input), "test.asm", opts)
+		},
+	}
+
+	for name, assemble := range assemblers {
+		program, err := assemble("@a\n@LED\n@b\n@a\n")
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		if fmt.Sprint(program.Words) != "[100 24577 101 100]" {
+			t.Fatalf("%v: expected=[100 24577 101 100], but got=%v", name, program.Words)
+		}
+
+		_, err = assemble("@a\n@b\n@c\n")
+		if err == nil || !strings.Contains(err.Error(), "test.asm:3:2: ") ||
+			!strings.Contains(err.Error(), "variables may use RAM[100] to RAM[101]") {
+			t.Fatalf("%v: expected variable space error, but got=%v", name, err)
+		}
+
+		_, err = assemble("@0\n@1\n(L)\n@2\n@3\n// end\n@L\n@5\n")
+		if err == nil || !strings.Contains(err.Error(), "test.asm:7:1: ") ||
+			!strings.Contains(err.Error(), "program has 6 instructions, but ROM holds 4") {
+			t.Fatalf("%v: expected ROM overflow error, but got=%v", name, err)
+		}
+	}
+}
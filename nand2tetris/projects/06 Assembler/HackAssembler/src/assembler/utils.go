@@ -0,0 +1,22 @@
+package assembler
+
+import (
+	"strings"
+	"unicode"
+)
+
+func IsSymbol(symbol string) bool {
+	if len(symbol) == 0 || unicode.IsDigit(rune(symbol[0])) {
+		return false
+	}
+
+	for _, char := range symbol {
+		if unicode.IsDigit(char) ||
+			unicode.IsLetter(char) ||
+			strings.ContainsAny(string(char), ".$:_") {
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/ishwar00/HackAssembler/files"
+)
+
+// outcome of assembling one file
+type fileResult struct {
+	path         string
+	instructions int
+	warnings     int
+	report       string // messages written while assembling the file
+	err          error
+}
+
+// turns arguments into paths of .asm files, a directory is searched recursively
+// for .asm files, a glob pattern is expanded and anything else is taken as a
+// file path. Every file appears once, in the order of arguments.
+func expandPaths(args []string) ([]string, error) {
+	filePaths := []string{}
+	seen := map[string]bool{}
+	add := func(filePath string) {
+		filePath = filepath.Clean(filePath)
+		if !seen[filePath] {
+			seen[filePath] = true
+			filePaths = append(filePaths, filePath)
+		}
+	}
+
+	for _, arg := range args {
+		matches := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			var err error
+			matches, err = filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("malformed pattern %v: %w", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %v", arg)
+			}
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || !info.IsDir() {
+				add(match) // missing file is reported when it is assembled
+				continue
+			}
+			found, err := files.FindAsmFiles(match)
+			if err != nil {
+				return nil, err
+			}
+			for _, filePath := range found {
+				add(filePath)
+			}
+		}
+	}
+	return filePaths, nil
+}
+
+// returns error if two sources write the same output file, eg: a/Prog.asm and
+// b/Prog.asm with -outdir, one would silently replace the other. outputs gives
+// paths of files written for a source.
+func checkOutputPaths(filePaths []string, outputs func(filePath string) []string) error {
+	writtenBy := map[string]string{} // key: output path, value: source
+	for _, filePath := range filePaths {
+		for _, path := range outputs(filePath) {
+			if path == stdio {
+				continue
+			}
+			path = filepath.Clean(path)
+			if source, ok := writtenBy[path]; ok {
+				return fmt.Errorf("%v and %v are both written to %v", source, filePath, path)
+			}
+			writtenBy[path] = filePath
+		}
+	}
+	return nil
+}
+
+// assembles filePaths with at most workers files at a time, results are
+// in the same order as filePaths.
+func assembleAll(filePaths []string, workers int, assemble func(filePath string, log io.Writer) fileResult) []fileResult {
+	if workers < 1 {
+		workers = 1
+	}
+	results := make([]fileResult, len(filePaths))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				var log bytes.Buffer
+				results[i] = assemble(filePaths[i], &log)
+				results[i].report = log.String()
+			}
+		}()
+	}
+	for i := range filePaths {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// writes a table with a row for every file followed by totals,
+// returns number of files which failed to assemble.
+func writeSummary(w io.Writer, results []fileResult) int {
+	width := len("file")
+	for _, result := range results {
+		width = max(width, len(result.path))
+	}
+
+	failed, instructions, warnings := 0, 0, 0
+	fmt.Fprintf(w, "%-*s  %-6s  %12s  %8s\n", width, "file", "status", "instructions", "warnings")
+	for _, result := range results {
+		status := color.GreenString("%-6s", "ok")
+		if result.err != nil {
+			status = color.RedString("%-6s", "failed")
+			failed++
+		}
+		instructions += result.instructions
+		warnings += result.warnings
+		fmt.Fprintf(w, "%-*s  %s  %12d  %8d\n", width, result.path, status, result.instructions, result.warnings)
+	}
+	fmt.Fprintf(w, "%d files, %d assembled, %d failed, %d instructions, %d warnings\n",
+		len(results), len(results)-failed, failed, instructions, warnings)
+	return failed
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
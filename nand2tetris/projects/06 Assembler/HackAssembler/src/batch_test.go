@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandPaths(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a/One.asm", "a/b/Two.asm", "a/b/notes.txt", "c/Three.asm"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("@0\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"a"}, []string{"a/One.asm", "a/b/Two.asm"}},
+		{[]string{"*/Three.asm", "a/b"}, []string{"c/Three.asm", "a/b/Two.asm"}},
+		{[]string{"*"}, []string{"a/One.asm", "a/b/Two.asm", "c/Three.asm"}},
+		{[]string{"c/Three.asm", "c", "./c/Three.asm"}, []string{"c/Three.asm"}},
+		{[]string{"missing.asm"}, []string{"missing.asm"}},
+	}
+
+	for i, tt := range tests {
+		args := []string{}
+		for _, arg := range tt.args {
+			args = append(args, filepath.Join(root, arg))
+		}
+		filePaths, err := expandPaths(args)
+		if err != nil {
+			t.Fatalf("tests[%d]: %v", i, err)
+		}
+
+		got := []string{}
+		for _, filePath := range filePaths {
+			rel, _ := filepath.Rel(root, filePath)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+			t.Fatalf("tests[%d]: expected=%v, but got=%v", i, tt.expected, got)
+		}
+	}
+
+	if _, err := expandPaths([]string{filepath.Join(root, "*.hack")}); err == nil {
+		t.Fatalf("expected error for pattern matching nothing")
+	}
+}
+
+func TestAssembleAll(t *testing.T) {
+	filePaths := []string{}
+	for i := 0; i < 20; i++ {
+		filePaths = append(filePaths, fmt.Sprintf("Prog%d.asm", i))
+	}
+
+	results := assembleAll(filePaths, 4, func(filePath string, log io.Writer) fileResult {
+		fmt.Fprintf(log, "assembled %v", filePath)
+		result := fileResult{path: filePath, instructions: len(filePath)}
+		if strings.HasSuffix(filePath, "3.asm") {
+			result.err = errors.New("failed")
+		}
+		return result
+	})
+
+	for i, result := range results {
+		if result.path != filePaths[i] || result.report != "assembled "+filePaths[i] {
+			t.Fatalf("results[%d]: expected result of %v, but got=%+v", i, filePaths[i], result)
+		}
+	}
+
+	var summary bytes.Buffer
+	failed := writeSummary(&summary, results)
+	if failed != 2 {
+		t.Fatalf("expected 2 failed files, but got=%d", failed)
+	}
+	if !strings.Contains(summary.String(), "20 files, 18 assembled, 2 failed") {
+		t.Fatalf("expected totals in summary, but got=\n%v", summary.String())
+	}
+}
+
+func TestCheckOutputPaths(t *testing.T) {
+	tests := []struct {
+		filePaths []string
+		outdir    string
+		expected  string // part of error, empty if there is none
+	}{
+		{[]string{"a/Prog.asm", "b/Prog.asm"}, "", ""},
+		{[]string{"a/Prog.asm", "b/Prog.asm"}, "out", "a/Prog.asm and b/Prog.asm are both written to out/Prog.hack"},
+		{[]string{"a/Prog.asm", "b/Main.asm"}, "out", ""},
+		{[]string{"a/Prog.asm", "a/Prog"}, "", "a/Prog.asm and a/Prog are both written to a/Prog.hack"},
+		{[]string{stdio}, "", ""},
+	}
+
+	for i, tt := range tests {
+		err := checkOutputPaths(tt.filePaths, func(filePath string) []string {
+			return []string{codePath(filePath, ".hack", "", tt.outdir)}
+		})
+		got := ""
+		if err != nil {
+			got = filepath.ToSlash(err.Error())
+		}
+		if (tt.expected == "") != (err == nil) || !strings.Contains(got, tt.expected) {
+			t.Fatalf("tests[%d]: expected=%q, but got=%q", i, tt.expected, got)
+		}
+	}
+}
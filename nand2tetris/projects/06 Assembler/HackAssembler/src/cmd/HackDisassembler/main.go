@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/ishwar00/HackAssembler/disassembler"
+	"github.com/ishwar00/HackAssembler/formats"
+)
+
+const doc = `
+ HackDisassembler [flags] example/path/prog1.hack example1/path1/prog2.mif ...
+
+ Reads machine code of one or more programs and writes it back as Hack
+ assembly to standard output. Format of each file is known from its
+ extension, unless given with -format.
+
+ flags:
+`
+
+var format = flag.String("format", "",
+	"format of input files, one of "+strings.Join(formats.Names(), ", "))
+
+var addresses = flag.Bool("addresses", false, "append ROM address of every instruction as a comment")
+
+var strict = flag.Bool("strict", false,
+	"write only documented mnemonics, instructions with other comp bits get raw comp %Bacccccc and a comment")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), doc)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		color.Red("program needs arguments as machine code file paths, run with flag --help")
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, filePath := range flag.Args() {
+		if err := disassembleFile(filePath); err != nil {
+			color.Red("failed to disassemble %v: %v", filePath, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func disassembleFile(filePath string) error {
+	inputFormat, ok := formats.Lookup(*format)
+	if *format == "" {
+		inputFormat, ok = formats.ByExtension(filepath.Ext(filePath))
+	}
+	if !ok {
+		return fmt.Errorf("unknown format, use -format with one of %v", strings.Join(formats.Names(), ", "))
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	words, err := inputFormat.Read(file)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("// disassembly of %v\n", filePath)
+	return disassembler.Write(os.Stdout, words, disassembler.Options{Addresses: *addresses, Strict: *strict})
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/ishwar00/HackAssembler/assembler"
+	"github.com/ishwar00/HackAssembler/debugger"
+)
+
+const doc = `
+ hackdbg [flags] Prog.asm
+
+ Debugs Hack assembly on the emulator. Program is assembled first, so
+ breakpoints are set on labels, ROM addresses or source lines and RAM cells
+ are named by predefined symbols and variables, eg: to stop in a VM function
+ and watch the stack pointer
+
+	hackdbg Prog.asm
+	(hackdbg) break Main.fibonacci
+	(hackdbg) watch SP
+	(hackdbg) continue
+	(hackdbg) stack
+
+ Every instruction executed is recorded, back steps execution back. Commands
+ are read from standard input, type help for the list.
+
+ flags:
+`
+
+var trace = flag.Int("trace", debugger.DEFAULT_TRACE_SIZE, "number of instructions recorded for stepping back")
+
+var cycles = flag.Uint64("cycles", 100000000, "continue stops after this many instructions, 0 for no limit")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), doc)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fail("program needs an assembly file path as argument, run with flag --help")
+	}
+	filePath := flag.Arg(0)
+	if !strings.EqualFold(filepath.Ext(filePath), ".asm") {
+		fail("%v: expected a .asm file, labels and source lines of machine code are not known", filePath)
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		fail("%v", err)
+	}
+	program, err := assembler.Assemble(file, filePath)
+	file.Close()
+	if err != nil {
+		fail("%v", err)
+	}
+
+	d, err := debugger.New(program)
+	if err != nil {
+		fail("%v: %v", filePath, err)
+	}
+	d.TraceSize, d.MaxCycles = *trace, *cycles
+	if err := d.Interact(os.Stdin, os.Stdout); err != nil {
+		fail("%v", err)
+	}
+}
+
+// reports error on standard error and exits with status 1
+func fail(format string, a ...interface{}) {
+	fmt.Fprintln(os.Stderr, color.RedString(format, a...))
+	os.Exit(1)
+}
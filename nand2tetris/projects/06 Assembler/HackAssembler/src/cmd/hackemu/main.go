@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/ishwar00/HackAssembler/assembler"
+	"github.com/ishwar00/HackAssembler/emulator"
+	"github.com/ishwar00/HackAssembler/files"
+	"github.com/ishwar00/HackAssembler/formats"
+	"github.com/ishwar00/HackAssembler/jit"
+	"github.com/ishwar00/HackAssembler/profiler"
+)
+
+const doc = `
+ hackemu [flags] Prog.hack
+
+ Runs Hack machine code until program halts in its final loop
+ (END) @END 0;JMP, or for -cycles instructions, then prints RAM cells named
+ by -dump. Format of program is known from its extension, unless given with
+ -format, a .asm program is assembled first.
+
+ eg: multiply 6 by 7 with projects/04 mult
+	hackemu -set R0=6,R1=7 -dump R0-R2 Mult.hack
+
+ With -report or -profile instructions executed are counted per ROM address,
+ -report prints where cycles go per VM function, label and source line, and
+ -profile writes them for go tool pprof. Labels and lines are known only for
+ .asm programs, eg:
+	hackemu -report -profile prog.pb.gz Prog.asm
+	go tool pprof -top -lines prog.pb.gz
+
+ With -jit program is translated into Go closures before it runs, which is
+ faster for long runs, eg: Pong or tests of the OS. It can not be used with
+ -report or -profile.
+
+ flags:
+`
+
+var format = flag.String("format", "",
+	"format of program, one of "+strings.Join(formats.Names(), ", "))
+
+var cycles = flag.Uint64("cycles", 10000000, "stop after this many instructions, if program does not halt before, 0 for no limit")
+
+var set = flag.String("set", "", "comma separated RAM cells set before running, eg: R0=6,256=-1")
+
+var dump = flag.String("dump", "", "comma separated RAM cells and ranges printed after running, eg: R0-R2,SCREEN,256-260")
+
+var report = flag.Bool("report", false, "print cycles spent per VM function, label and source line")
+
+var top = flag.Int("top", 20, "number of entries of each section of -report, 0 for all")
+
+var profilePath = flag.String("profile", "", "write profile of cycles spent for go tool pprof to this file")
+
+var useJIT = flag.Bool("jit", false, "translate program into Go closures and run them, instead of interpreting it")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), doc)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fail("program needs a machine code file path as argument, run with flag --help")
+	}
+	filePath := flag.Arg(0)
+	program, err := files.ReadProgram(filePath, *format)
+	if err != nil {
+		fail("%v: %v", filePath, err)
+	}
+
+	computer := new(emulator.Computer)
+	if err := computer.Load(program.Words); err != nil {
+		fail("%v: %v", filePath, err)
+	}
+	if err := setCells(computer, *set); err != nil {
+		fail("-set: %v", err)
+	}
+	ranges, err := parseRanges(*dump)
+	if err != nil {
+		fail("-dump: %v", err)
+	}
+
+	if *useJIT && (*report || *profilePath != "") {
+		fail("-jit can not be used with -report or -profile")
+	}
+
+	var executed uint64
+	var halted bool
+	var prof *profiler.Profiler
+	if *report || *profilePath != "" {
+		prof = profiler.New(program)
+		executed, halted = prof.Run(computer, *cycles)
+	} else if *useJIT {
+		executed, halted = jit.Compile(program.Words).Run(computer, *cycles)
+	} else {
+		executed, halted = computer.Run(*cycles)
+	}
+	if halted {
+		fmt.Printf("halted after %d cycles at PC=%d\n", executed, computer.PC)
+	} else {
+		fmt.Printf("stopped after %d cycles without halting, PC=%d\n", executed, computer.PC)
+	}
+	for _, r := range ranges {
+		for address := r[0]; address <= r[1]; address++ {
+			fmt.Printf("RAM[%d]\t%d\n", address, int16(computer.RAM[address]))
+		}
+	}
+
+	if *report {
+		fmt.Println()
+		if err := prof.WriteReport(os.Stdout, *top); err != nil {
+			fail("%v", err)
+		}
+	}
+	if *profilePath != "" {
+		var out bytes.Buffer
+		if err := prof.WritePprof(&out); err != nil {
+			fail("%v", err)
+		}
+		if err := os.WriteFile(*profilePath, out.Bytes(), 0644); err != nil {
+			fail("%v", err)
+		}
+	}
+}
+
+// R0=6,256=-1
+func setCells(computer *emulator.Computer, cells string) error {
+	for _, cell := range splitList(cells) {
+		name, value, ok := strings.Cut(cell, "=")
+		if !ok {
+			return fmt.Errorf("malformed %q, expected ADDRESS=VALUE", cell)
+		}
+		address, err := parseAddress(name)
+		if err != nil {
+			return err
+		}
+		v, err := strconv.ParseInt(strings.TrimSpace(value), 0, 32)
+		if err != nil || v < -32768 || v > 65535 {
+			return fmt.Errorf("value %q of %v is not a 16 bit number", value, name)
+		}
+		computer.RAM[address] = uint16(v)
+	}
+	return nil
+}
+
+// R0-R2,SCREEN -> [[0 2] [16384 16384]]
+func parseRanges(list string) ([][2]int, error) {
+	ranges := [][2]int{}
+	for _, item := range splitList(list) {
+		first, last, isRange := strings.Cut(item, "-")
+		from, err := parseAddress(first)
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if isRange {
+			if to, err = parseAddress(last); err != nil {
+				return nil, err
+			}
+		}
+		if to < from {
+			return nil, fmt.Errorf("range %v is empty", item)
+		}
+		ranges = append(ranges, [2]int{from, to})
+	}
+	return ranges, nil
+}
+
+// RAM address as number or predefined symbol, eg: 256, 0x4000, SP or SCREEN
+func parseAddress(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if address, ok := assembler.DefaultTarget().Symbols[s]; ok {
+		return address, nil
+	}
+	address, err := strconv.ParseInt(s, 0, 32)
+	if err != nil || address < 0 || address >= emulator.RAM_SIZE {
+		return 0, fmt.Errorf("%q is not a RAM address or predefined symbol", s)
+	}
+	return int(address), nil
+}
+
+func splitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// reports error on standard error and exits with status 1
+func fail(format string, a ...interface{}) {
+	fmt.Fprintln(os.Stderr, color.RedString(format, a...))
+	os.Exit(1)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/ishwar00/HackAssembler/assembler"
+	"github.com/ishwar00/HackAssembler/files"
+)
+
+const doc = `
+ hackfmt [flags] [path ...]
+
+ Formats Hack assembly: instructions are indented by a tab, labels and
+ directives are not, spaces inside instructions are removed, trailing comments
+ of consecutive lines are aligned. Paths may be .asm files or directories,
+ which are searched recursively for .asm files. Without paths, standard input
+ is formatted to standard output.
+
+ By default formatted files are written to standard output, with -w they are
+ rewritten in place and with -check files which are not formatted are listed
+ and exit status is 1, eg: in CI.
+
+ flags:
+`
+
+var write = flag.Bool("w", false, "rewrite files in place instead of writing them to standard output")
+
+var check = flag.Bool("check", false, "list files which are not formatted and exit with status 1 if there are any")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), doc)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *write && *check {
+		fail("-w and -check can not be used together")
+	}
+	if flag.NArg() == 0 {
+		if *write {
+			fail("-w needs file paths")
+		}
+		source, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fail("%v", err)
+		}
+		if !formatSource("<standard input>", source) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	filePaths, err := findAsmFiles(flag.Args())
+	if err != nil {
+		fail("%v", err)
+	}
+	ok := true
+	for _, filePath := range filePaths {
+		source, err := os.ReadFile(filePath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, color.RedString("%v", err))
+			ok = false
+			continue
+		}
+		ok = formatSource(filePath, source) && ok
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// formats source read from filePath as flags say, returns false if it
+// failed or source is not formatted in -check mode.
+func formatSource(filePath string, source []byte) bool {
+	formatted, err := assembler.Format(bytes.NewReader(source))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, color.RedString("%v: %v", filePath, err))
+		return false
+	}
+
+	switch {
+	case *check:
+		if !bytes.Equal(source, formatted) {
+			fmt.Println(filePath)
+			return false
+		}
+	case *write:
+		if bytes.Equal(source, formatted) {
+			return true
+		}
+		if err := files.WriteFile(filePath, func(w io.Writer) error {
+			_, err := w.Write(formatted)
+			return err
+		}); err != nil {
+			fmt.Fprintln(os.Stderr, color.RedString("%v", err))
+			return false
+		}
+	default:
+		os.Stdout.Write(formatted)
+	}
+	return true
+}
+
+// returns .asm files named by paths, directories are searched recursively.
+func findAsmFiles(paths []string) ([]string, error) {
+	filePaths := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			filePaths = append(filePaths, path)
+			continue
+		}
+
+		found, err := files.FindAsmFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		filePaths = append(filePaths, found...)
+	}
+	return filePaths, nil
+}
+
+// reports error on standard error and exits with status 1
+func fail(format string, a ...interface{}) {
+	fmt.Fprintln(os.Stderr, color.RedString(format, a...))
+	os.Exit(1)
+}
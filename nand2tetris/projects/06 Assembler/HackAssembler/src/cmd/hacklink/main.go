@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/ishwar00/HackAssembler/assembler"
+	"github.com/ishwar00/HackAssembler/files"
+	"github.com/ishwar00/HackAssembler/formats"
+	"github.com/ishwar00/HackAssembler/linker"
+	"github.com/ishwar00/HackAssembler/object"
+)
+
+const doc = `
+ hacklink [flags] Main.hobj Math.hobj Memory.hobj Sys.hobj ...
+
+ Combines relocatable objects written by HackAssembler -c into one program.
+ Objects are placed in ROM in the given order, so the object starting the
+ program comes first. An object exports labels listed by .export, or without
+ .export every label with a dot, eg: VM functions Foo.bar, other labels are
+ private. An object uses a label exported by another one by declaring it
+ with .import, other symbols it does not define are its own variables.
+ Machine code is written to file named after the first object, eg: Main.hack,
+ unless -o names it.
+
+ flags:
+`
+
+var output = flag.String("o", "", "write machine code to this file, - for standard output")
+
+var format = flag.String("format", "hack",
+	"output format of machine code, one of "+strings.Join(formats.Names(), ", "))
+
+var targetPath = flag.String("target", "",
+	"file describing modified Hack machine: predefined symbols, RAM for variables and ROM size,\nsee assembler.ReadTarget")
+
+var symbols = flag.String("symbols", "",
+	"export symbol table of linked program to this file, as JSON if it ends with .json,\notherwise as .sym text")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), doc)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	outputFormat, ok := formats.Lookup(*format)
+	if !ok {
+		fail("unknown format %v, expected one of %v", *format, strings.Join(formats.Names(), ", "))
+	}
+	if flag.NArg() == 0 {
+		fail("program needs arguments as %v object file paths, run with flag --help", object.Extension)
+	}
+
+	target := assembler.DefaultTarget()
+	if *targetPath != "" {
+		t, err := files.ReadTarget(*targetPath)
+		if err != nil {
+			fail("%v: %v", *targetPath, err)
+		}
+		target = t
+	}
+
+	objects := []object.Object{}
+	for _, filePath := range flag.Args() {
+		obj, err := readObject(filePath)
+		if err != nil {
+			fail("failed to read %v: %v", filePath, err)
+		}
+		objects = append(objects, obj)
+	}
+
+	program, err := linker.Link(objects, target)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fail("failed to link %v", strings.Join(flag.Args(), " "))
+	}
+
+	hackPath := *output
+	if hackPath == "" {
+		first := flag.Arg(0)
+		hackPath = strings.TrimSuffix(first, filepath.Ext(first)) + outputFormat.Extension()
+	}
+	err = files.WriteFile(hackPath, func(w io.Writer) error {
+		return outputFormat.Write(w, program.Words)
+	})
+	if err != nil {
+		fail("%v", err)
+	}
+
+	if *symbols != "" {
+		writeSymbols := assembler.WriteSymbols
+		if strings.EqualFold(filepath.Ext(*symbols), ".json") {
+			writeSymbols = assembler.WriteSymbolsJSON
+		}
+		err = files.WriteFile(*symbols, func(w io.Writer) error {
+			return writeSymbols(w, program)
+		})
+		if err != nil {
+			fail("%v", err)
+		}
+	}
+
+	if hackPath != "-" {
+		TI := fmt.Sprint(len(program.Words))
+		fmt.Println(color.GreenString("linked"), color.YellowString(TI), color.GreenString("instructions into"), hackPath)
+	}
+}
+
+// reports error on standard error and exits with status 1
+func fail(format string, a ...interface{}) {
+	fmt.Fprintln(os.Stderr, color.RedString(format, a...))
+	os.Exit(1)
+}
+
+func readObject(path string) (object.Object, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return object.Object{}, err
+	}
+	defer file.Close()
+	return object.Read(file)
+}
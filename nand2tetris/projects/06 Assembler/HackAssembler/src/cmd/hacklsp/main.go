@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/ishwar00/HackAssembler/lsp"
+)
+
+const doc = `
+ hacklsp
+
+ Language server for Hack assembly, editors start it and talk to it over
+ standard input and output. It reports errors and warnings of .asm files as
+ you type, goes to definition of symbols, finds their references, shows
+ address and machine code of instructions on hover, and completes symbols
+ after @ and mnemonics of C instructions.
+
+ eg: for neovim
+	vim.lsp.start({ name = "hacklsp", cmd = { "hacklsp" } })
+
+ flags:
+`
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), doc)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	color.NoColor = true // messages are shown by editor
+	if err := lsp.Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "hacklsp:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/ishwar00/HackAssembler/emulator"
+	"github.com/ishwar00/HackAssembler/files"
+	"github.com/ishwar00/HackAssembler/formats"
+)
+
+const doc = `
+ hackscreen [flags] Prog.hack
+
+ Runs Hack machine code and shows its screen in the terminal. Format of program
+ is known from its extension, unless given with -format, a .asm program is
+ assembled first.
+
+ By default program runs for -cycles instructions, or until it halts, then
+ screen is drawn once, with -png it is saved as image too. Keys are pressed
+ by a script of CYCLE:KEY events, eg: tests without a terminal
+
+	hackscreen -keys "0:RIGHT 400000:NONE" -png pong.png Pong.hack
+
+ With -live screen is redrawn while program runs and keys pressed in the
+ terminal are given to program, a key is held for -hold after it was last
+ seen, as terminals do not tell when keys are released. Ctrl-C exits.
+
+ flags:
+`
+
+var format = flag.String("format", "",
+	"format of program, one of "+strings.Join(formats.Names(), ", "))
+
+var cycles = flag.Uint64("cycles", 10000000, "stop after this many instructions, if program does not halt before, 0 for no limit")
+
+var keys = flag.String("keys", "", "key script, eg: 0:a,1000:NONE, or @file to read it from file")
+
+var pngPath = flag.String("png", "", "save screen as PNG image to this file when program stops")
+
+var mode = flag.String("mode", "braille", "characters screen is drawn with, braille or halfblock")
+
+var scale = flag.Int("scale", 1, "draw every scale x scale block of pixels as one dot")
+
+var live = flag.Bool("live", false, "redraw screen while program runs and read keys from terminal")
+
+var speed = flag.Uint64("speed", 2000000, "instructions per second with -live")
+
+var hold = flag.Duration("hold", 150*time.Millisecond, "how long a key is held after it was last seen with -live")
+
+const FRAMES_PER_SECOND = 30
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), doc)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fail("program needs a machine code file path as argument, run with flag --help")
+	}
+	filePath := flag.Arg(0)
+	program, err := files.ReadProgram(filePath, *format)
+	if err != nil {
+		fail("%v: %v", filePath, err)
+	}
+	computer := new(emulator.Computer)
+	if err := computer.Load(program.Words); err != nil {
+		fail("%v: %v", filePath, err)
+	}
+
+	renderMode := map[string]int{"braille": emulator.BRAILLE_MODE, "halfblock": emulator.HALF_BLOCK_MODE}
+	m, ok := renderMode[*mode]
+	if !ok {
+		fail("-mode: unknown mode %v, expected braille or halfblock", *mode)
+	}
+	if *scale < 1 {
+		fail("-scale: %d is not a positive number", *scale)
+	}
+	script := *keys
+	if strings.HasPrefix(script, "@") {
+		content, err := os.ReadFile(script[1:])
+		if err != nil {
+			fail("-keys: %v", err)
+		}
+		script = string(content)
+	}
+	events, err := emulator.ParseKeys(script)
+	if err != nil {
+		fail("-keys: %v", err)
+	}
+
+	if *live {
+		runLive(computer, events, m)
+	} else {
+		executed, halted := computer.RunKeys(events, *cycles)
+		computer.Render(os.Stdout, m, *scale)
+		if halted {
+			fmt.Printf("halted after %d cycles at PC=%d\n", executed, computer.PC)
+		} else {
+			fmt.Printf("stopped after %d cycles without halting, PC=%d\n", executed, computer.PC)
+		}
+	}
+
+	if *pngPath != "" {
+		var image bytes.Buffer
+		if err := computer.WritePNG(&image); err != nil {
+			fail("%v", err)
+		}
+		if err := os.WriteFile(*pngPath, image.Bytes(), 0644); err != nil {
+			fail("%v", err)
+		}
+	}
+}
+
+// runs program in real time, redrawing screen every frame, until Ctrl-C.
+func runLive(computer *emulator.Computer, events []emulator.KeyEvent, m int) {
+	restore, err := rawTerminal()
+	if err != nil {
+		fail("-live: terminal can not be put in raw mode: %v", err)
+	}
+	defer restore()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	input := make(chan []byte)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+			input <- append([]byte(nil), buf[:n]...)
+		}
+	}()
+
+	frame := time.NewTicker(time.Second / FRAMES_PER_SECOND)
+	defer frame.Stop()
+	perFrame := *speed / FRAMES_PER_SECOND
+	if perFrame == 0 {
+		perFrame = 1 // 0 would run without limit inside a single frame
+	}
+	script := emulator.KeyScript{Events: events}
+	var pressedAt time.Time
+	fmt.Print("\x1b[2J\x1b[?25l") // clear terminal and hide cursor
+	defer fmt.Print("\x1b[?25h")
+
+	for {
+		select {
+		case <-interrupt:
+			return
+		case keys := <-input:
+			for len(keys) > 0 {
+				code, n := emulator.DecodeKey(keys)
+				if code != 0 {
+					computer.RAM[emulator.KBD] = code
+					pressedAt = time.Now()
+				}
+				keys = keys[n:]
+			}
+		case <-frame.C:
+			if !pressedAt.IsZero() && time.Since(pressedAt) > *hold {
+				computer.RAM[emulator.KBD] = 0
+				pressedAt = time.Time{}
+			}
+			if *cycles == 0 || computer.Cycles < *cycles {
+				run := perFrame
+				if *cycles != 0 && *cycles-computer.Cycles < run {
+					run = *cycles - computer.Cycles
+				}
+				script.Run(computer, run)
+			}
+			fmt.Print("\x1b[H")
+			computer.Render(os.Stdout, m, *scale)
+			fmt.Printf("cycles %d  PC=%d  KBD=%d  Ctrl-C exits\x1b[K", computer.Cycles, computer.PC, computer.RAM[emulator.KBD])
+		}
+	}
+}
+
+// turns off line buffering and echo of terminal, so keys are read as they
+// are pressed, returned function restores terminal.
+func rawTerminal() (restore func(), err error) {
+	stty := func(args ...string) (string, error) {
+		cmd := exec.Command("stty", args...)
+		cmd.Stdin = os.Stdin
+		out, err := cmd.Output()
+		return strings.TrimSpace(string(out)), err
+	}
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return nil, err
+	}
+	return func() { stty(saved) }, nil
+}
+
+// reports error on standard error and exits with status 1
+func fail(format string, a ...interface{}) {
+	fmt.Fprintln(os.Stderr, color.RedString(format, a...))
+	os.Exit(1)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/ishwar00/HackAssembler/tst"
+)
+
+const doc = `
+ hacktest [flags] Script.tst ...
+
+ Runs test scripts of the CPU emulator without its graphical interface, eg: in
+ CI. Programs loaded by a script may be .asm, which is assembled, or machine
+ code in a format known from its extension. Output of each script is written
+ to its output-file and compared line by line with its compare-to file, a
+ script fails at the first line which differs.
+
+ eg: test projects/04 mult
+	hacktest Mult.tst
+
+ flags:
+`
+
+var outDir = flag.String("outdir", "", "directory .out files are written to, default is directory of script")
+
+var cycles = flag.Uint64("cycles", 10000000, "instructions a repeat without count runs, if program does not halt before, 0 for no limit")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), doc)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fail("hacktest needs test script paths as arguments, run with flag --help")
+	}
+	opts := tst.Options{Echo: os.Stdout, MaxCycles: *cycles, OutputDir: *outDir}
+	failed := 0
+	for _, filePath := range flag.Args() {
+		if err := tst.RunFile(filePath, opts); err != nil {
+			fmt.Printf("%v %v\n", color.RedString("FAIL"), filePath)
+			fmt.Fprintln(os.Stderr, color.RedString("%v", err))
+			failed++
+			continue
+		}
+		fmt.Printf("%v   %v\n", color.GreenString("ok"), filePath)
+	}
+	if failed != 0 {
+		fmt.Printf("%d of %d scripts failed\n", failed, flag.NArg())
+		os.Exit(1)
+	}
+}
+
+// reports error on standard error and exits with status 1
+func fail(format string, a ...interface{}) {
+	fmt.Fprintln(os.Stderr, color.RedString(format, a...))
+	os.Exit(1)
+}
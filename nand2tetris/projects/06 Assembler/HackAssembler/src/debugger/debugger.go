@@ -0,0 +1,356 @@
+// Package debugger runs an assembled program on the emulator under control of
+// breakpoints and watchpoints. Labels and source lines of the program are
+// known, so locations are named by them, and every instruction executed is
+// recorded, so execution can be stepped back.
+package debugger
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ishwar00/HackAssembler/assembler"
+	"github.com/ishwar00/HackAssembler/emulator"
+)
+
+// number of instructions recorded for stepping back, unless changed
+const DEFAULT_TRACE_SIZE = 1000000
+
+// reasons execution stopped
+const (
+	STEP_STOP       = iota // requested number of instructions executed
+	BREAKPOINT_STOP        // PC reached a breakpoint
+	WATCHPOINT_STOP        // value of watched RAM cell changed
+	HALT_STOP              // program is in its final loop, see emulator.Computer.Halted
+	LIMIT_STOP             // maximum number of instructions executed
+)
+
+// Stop tells why execution stopped, Address, Old and New are the RAM cell
+// and its values for WATCHPOINT_STOP.
+type Stop struct {
+	Reason   int
+	Address  int
+	Old, New uint16
+}
+
+// undo record of one instruction, RAM cell is recorded if it was written.
+type undo struct {
+	a, d, pc uint16
+	wrote    bool
+	address  uint16
+	old      uint16
+}
+
+type Debugger struct {
+	Computer *emulator.Computer
+	Program  assembler.Program
+
+	// maximum number of instructions which can be stepped back
+	TraceSize int
+
+	// continue command of Interact stops after this many instructions,
+	// 0 means no limit
+	MaxCycles uint64
+
+	breakpoints map[uint16]string // key: ROM address, value: location as given
+	watchpoints map[int]string    // key: RAM address, value: cell as given
+	trace       []undo
+	labelsAt    map[int][]string // key: ROM address, value: labels declared there
+	labels      []int            // addresses of labels, sorted
+}
+
+// New loads program into a new computer.
+func New(program assembler.Program) (*Debugger, error) {
+	d := &Debugger{
+		Computer:    new(emulator.Computer),
+		Program:     program,
+		TraceSize:   DEFAULT_TRACE_SIZE,
+		breakpoints: map[uint16]string{},
+		watchpoints: map[int]string{},
+		labelsAt:    map[int][]string{},
+	}
+	if err := d.Computer.Load(program.Words); err != nil {
+		return nil, err
+	}
+	for _, label := range program.Symbols.Symbols(assembler.LABEL_SYMBOL) {
+		address := program.Symbols.GetAddress(label)
+		if len(d.labelsAt[address]) == 0 {
+			d.labels = append(d.labels, address)
+		}
+		d.labelsAt[address] = append(d.labelsAt[address], label)
+	}
+	return d, nil
+}
+
+// Reset starts program again, RAM is kept and trace is cleared.
+func (d *Debugger) Reset() {
+	d.Computer.Reset()
+	d.trace = d.trace[:0]
+}
+
+// ResolveROM returns ROM address of location, which is a label, a ROM address
+// or a source line as FILE:LINE or :LINE, tried in that order. A line without
+// instruction resolves to the first instruction after it.
+func (d *Debugger) ResolveROM(location string) (uint16, error) {
+	st := d.Program.Symbols
+	if st.Contains(location) && st.Kind(location) == assembler.LABEL_SYMBOL {
+		address := st.GetAddress(location)
+		if address >= len(d.Program.Words) {
+			return 0, fmt.Errorf("label %v is after the last instruction of program", location)
+		}
+		return uint16(address), nil
+	}
+
+	if address, err := parseInt(location, 32); err == nil && address >= 0 {
+		if address >= int64(len(d.Program.Words)) {
+			return 0, fmt.Errorf("ROM address %d is not in program, it has %d instructions", address, len(d.Program.Words))
+		}
+		return uint16(address), nil
+	}
+
+	// labels may contain ':', so location is a source line only if it is not a label
+	if colon := strings.LastIndex(location, ":"); colon >= 0 {
+		file, line := location[:colon], location[colon+1:]
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("%q is not a source line, expected FILE:LINE or :LINE", location)
+		}
+		best := -1
+		for address, instrInfo := range d.Program.Instrs {
+			if !d.inFile(instrInfo, file) || instrInfo.AtLine < n {
+				continue
+			}
+			if best < 0 || instrInfo.AtLine < d.Program.Instrs[best].AtLine {
+				best = address
+			}
+		}
+		if best < 0 {
+			return 0, fmt.Errorf("no instruction at or after line %v", location)
+		}
+		return uint16(best), nil
+	}
+	return 0, fmt.Errorf("%v is not a label, ROM address or source line", location)
+}
+
+// instruction is from file, "" is the main file of program.
+func (d *Debugger) inFile(instrInfo assembler.InstructionInfo, file string) bool {
+	if file == "" {
+		return instrInfo.InFile == d.Program.Name
+	}
+	return instrInfo.InFile == file || filepath.Base(instrInfo.InFile) == file
+}
+
+// ResolveRAM returns RAM address of cell, which is a predefined symbol, a
+// variable of program, an address or RAM[address].
+func (d *Debugger) ResolveRAM(cell string) (int, error) {
+	st := d.Program.Symbols
+	if st.Contains(cell) {
+		switch st.Kind(cell) {
+		case assembler.PREDEFINED_SYMBOL, assembler.VARIABLE_SYMBOL:
+			return st.GetAddress(cell), nil
+		}
+		kind := "label"
+		if st.Kind(cell) == assembler.CONSTANT_SYMBOL {
+			kind = "constant"
+		}
+		return 0, fmt.Errorf("%v is a %v, not a RAM cell", cell, kind)
+	}
+	index := cell
+	if strings.HasPrefix(cell, "RAM[") && strings.HasSuffix(cell, "]") {
+		index = cell[4 : len(cell)-1]
+	}
+	address, err := parseInt(index, 32)
+	if err != nil || address < 0 || address >= emulator.RAM_SIZE {
+		return 0, fmt.Errorf("%v is not a RAM address, predefined symbol or variable", cell)
+	}
+	return int(address), nil
+}
+
+// Break sets breakpoint at location, see ResolveROM.
+func (d *Debugger) Break(location string) (uint16, error) {
+	address, err := d.ResolveROM(location)
+	if err != nil {
+		return 0, err
+	}
+	d.breakpoints[address] = location
+	return address, nil
+}
+
+// Delete removes breakpoint at location.
+func (d *Debugger) Delete(location string) error {
+	address, err := d.ResolveROM(location)
+	if err != nil {
+		return err
+	}
+	if _, ok := d.breakpoints[address]; !ok {
+		return fmt.Errorf("there is no breakpoint at %v", location)
+	}
+	delete(d.breakpoints, address)
+	return nil
+}
+
+// Breakpoints returns ROM addresses of breakpoints in order.
+func (d *Debugger) Breakpoints() []uint16 {
+	addresses := []uint16{}
+	for address := range d.breakpoints {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i] < addresses[j] })
+	return addresses
+}
+
+// Watch stops execution when value of RAM cell changes, see ResolveRAM.
+func (d *Debugger) Watch(cell string) (int, error) {
+	address, err := d.ResolveRAM(cell)
+	if err != nil {
+		return 0, err
+	}
+	d.watchpoints[address] = cell
+	return address, nil
+}
+
+// Unwatch removes watchpoint of RAM cell.
+func (d *Debugger) Unwatch(cell string) error {
+	address, err := d.ResolveRAM(cell)
+	if err != nil {
+		return err
+	}
+	if _, ok := d.watchpoints[address]; !ok {
+		return fmt.Errorf("%v is not watched", cell)
+	}
+	delete(d.watchpoints, address)
+	return nil
+}
+
+// Watchpoints returns RAM addresses of watchpoints in order.
+func (d *Debugger) Watchpoints() []int {
+	addresses := []int{}
+	for address := range d.watchpoints {
+		addresses = append(addresses, address)
+	}
+	sort.Ints(addresses)
+	return addresses
+}
+
+// Step executes n instructions, it stops early if a watched cell changes or
+// program halts.
+func (d *Debugger) Step(n int) Stop {
+	for i := 0; i < n; i++ {
+		if d.Computer.Halted() {
+			return Stop{Reason: HALT_STOP}
+		}
+		if stop, ok := d.step(); ok {
+			return stop
+		}
+	}
+	return Stop{Reason: STEP_STOP}
+}
+
+// Continue executes instructions until PC reaches a breakpoint, a watched
+// cell changes, program halts or maxCycles instructions are executed, 0 means
+// no limit. Instruction at PC is executed even if there is a breakpoint, so
+// execution continues from a breakpoint it stopped at.
+func (d *Debugger) Continue(maxCycles uint64) Stop {
+	for cycles := uint64(0); maxCycles == 0 || cycles < maxCycles; cycles++ {
+		if d.Computer.Halted() {
+			return Stop{Reason: HALT_STOP}
+		}
+		if stop, ok := d.step(); ok {
+			return stop
+		}
+		if _, ok := d.breakpoints[d.Computer.PC]; ok {
+			return Stop{Reason: BREAKPOINT_STOP}
+		}
+	}
+	return Stop{Reason: LIMIT_STOP}
+}
+
+// executes one instruction and records how to undo it, ok is true if a
+// watched cell changed.
+func (d *Debugger) step() (stop Stop, ok bool) {
+	c := d.Computer
+	record := undo{a: c.A, d: c.D, pc: c.PC}
+	instr := c.ROM[c.PC&(emulator.ROM_SIZE-1)]
+	if instr&0x8000 != 0 && instr&0x0008 != 0 { // C instruction with M in dest
+		record.wrote = true
+		record.address = c.A & (emulator.RAM_SIZE - 1)
+		record.old = c.RAM[record.address]
+	}
+	c.Step()
+
+	if d.TraceSize > 0 {
+		d.trace = append(d.trace, record)
+		if len(d.trace) >= 2*d.TraceSize { // drop oldest records
+			d.trace = append(d.trace[:0], d.trace[len(d.trace)-d.TraceSize:]...)
+		}
+	}
+
+	if record.wrote {
+		address := int(record.address)
+		if _, watched := d.watchpoints[address]; watched && c.RAM[address] != record.old {
+			return Stop{WATCHPOINT_STOP, address, record.old, c.RAM[address]}, true
+		}
+	}
+	return Stop{}, false
+}
+
+// Back undoes last n instructions, it returns number of instructions undone,
+// which is less than n if trace does not reach back so far.
+func (d *Debugger) Back(n int) int {
+	if limit := d.TraceSize; len(d.trace) > limit {
+		d.trace = d.trace[len(d.trace)-limit:]
+	}
+	undone := 0
+	c := d.Computer
+	for ; undone < n && len(d.trace) > 0; undone++ {
+		record := d.trace[len(d.trace)-1]
+		d.trace = d.trace[:len(d.trace)-1]
+		c.A, c.D, c.PC = record.a, record.d, record.pc
+		if record.wrote {
+			c.RAM[record.address] = record.old
+		}
+		c.Cycles--
+	}
+	return undone
+}
+
+// Where names ROM address by nearest label at or before it, eg: LOOP+2, and
+// source line of its instruction, eg: Mult.asm:14
+func (d *Debugger) Where(address uint16) string {
+	i := sort.SearchInts(d.labels, int(address)+1) - 1
+	where := strconv.Itoa(int(address))
+	if i >= 0 {
+		label := d.labelsAt[d.labels[i]][0]
+		if offset := int(address) - d.labels[i]; offset == 0 {
+			where = label
+		} else {
+			where = fmt.Sprintf("%v+%d", label, offset)
+		}
+	}
+	if int(address) < len(d.Program.Instrs) {
+		instrInfo := d.Program.Instrs[address]
+		where += fmt.Sprintf(" (%v:%d)", filepath.Base(instrInfo.InFile), instrInfo.AtLine)
+	}
+	return where
+}
+
+// Instruction returns source of instruction at ROM address.
+func (d *Debugger) Instruction(address uint16) string {
+	if int(address) < len(d.Program.Instrs) {
+		return d.Program.Instrs[address].Instr
+	}
+	return fmt.Sprintf("%016b", d.Computer.ROM[address&(emulator.ROM_SIZE-1)])
+}
+
+// parseInt parses s as decimal number, or as hex or binary number if it has
+// 0x or 0b prefix. Unlike strconv.ParseInt with base 0, leading 0 doesn't mean
+// octal.
+func parseInt(s string, bitSize int) (int64, error) {
+	digits := strings.TrimPrefix(s, "-")
+	if len(digits) > 2 && digits[0] == '0' && strings.ContainsRune("xXbB", rune(digits[1])) {
+		return strconv.ParseInt(s, 0, bitSize)
+	}
+	return strconv.ParseInt(s, 10, bitSize)
+}
@@ -0,0 +1,192 @@
+package debugger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ishwar00/HackAssembler/assembler"
+	"github.com/ishwar00/HackAssembler/files"
+)
+
+const multPath = "../../../../04 Machine Language/mult/Mult.asm"
+
+func load(t *testing.T, asmPath string) *Debugger {
+	t.Helper()
+	program, err := files.ReadProgram(asmPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := New(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestResolve(t *testing.T) {
+	d := load(t, multPath)
+	tests := []struct {
+		location string
+		expected uint16
+		err      string
+	}{
+		{"LOOP", 4, ""},
+		{"END", 18, ""},
+		{"3", 3, ""},
+		{"010", 10, ""}, // leading 0 is no octal prefix
+		{"0x10", 16, ""},
+		{"0b11", 3, ""},
+		{":19", 5, ""},
+		{":17", 4, ""}, // label declaration resolves to next instruction
+		{"Mult.asm:13", 0, ""},
+		{"NOPE", 0, "NOPE is not a label, ROM address or source line"},
+		{"R0", 0, "R0 is not a label, ROM address or source line"},
+		{"99", 0, "ROM address 99 is not in program, it has 20 instructions"},
+		{"-1", 0, "-1 is not a label, ROM address or source line"},
+		{":40", 0, "no instruction at or after line :40"},
+		{"Other.asm:13", 0, "no instruction at or after line Other.asm:13"},
+	}
+
+	for i, tt := range tests {
+		got, err := d.ResolveROM(tt.location)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Fatalf("tests[%d]: expected error=%q, but got=%v", i, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d]: %v", i, err)
+		}
+		if got != tt.expected {
+			t.Fatalf("tests[%d]: %v: expected=%d, but got=%d", i, tt.location, tt.expected, got)
+		}
+	}
+
+	// label declared after the last instruction is no ROM address of program
+	program, err := assembler.Assemble(strings.NewReader("@0\nD=A\n(TAIL)\n"), "tail.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tail, err := New(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := tail.Interact(strings.NewReader("break TAIL\nlist 0\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "error: label TAIL is after the last instruction of program") {
+		t.Fatalf("expected TAIL to be rejected, but got=\n%v", out.String())
+	}
+
+	// labels may contain ':', they are resolved before source lines
+	program, err = assembler.Assemble(strings.NewReader("@0\nD=A\n(a:1)\n@a:1\n0;JMP\n"), "colon.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	colon, err := New(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := colon.ResolveROM("a:1"); err != nil || got != 2 {
+		t.Fatalf("a:1: expected=2, but got=%d, %v", got, err)
+	}
+	if got, err := colon.ResolveROM(":1"); err != nil || got != 0 {
+		t.Fatalf(":1: expected=0, but got=%d, %v", got, err)
+	}
+
+	cells := map[string]int{"R2": 2, "SP": 0, "SCREEN": 16384, "i": 16, "300": 300, "RAM[7]": 7, "010": 10, "RAM[0x10]": 16}
+	for cell, expected := range cells {
+		if got, err := d.ResolveRAM(cell); err != nil || got != expected {
+			t.Fatalf("%v: expected=%d, but got=%d, %v", cell, expected, got, err)
+		}
+	}
+	if _, err := d.ResolveRAM("LOOP"); err == nil || err.Error() != "LOOP is a label, not a RAM cell" {
+		t.Fatalf("LOOP: expected label error, but got=%v", err)
+	}
+
+	if where := d.Where(6); where != "LOOP+2 (Mult.asm:20)" {
+		t.Fatalf("expected=%q, but got=%q", "LOOP+2 (Mult.asm:20)", where)
+	}
+}
+
+func TestRun(t *testing.T) {
+	d := load(t, multPath)
+	c := d.Computer
+	c.RAM[0], c.RAM[1] = 3, 4
+
+	if _, err := d.Break("LOOP"); err != nil {
+		t.Fatal(err)
+	}
+	if stop := d.Continue(1000); stop.Reason != BREAKPOINT_STOP || c.PC != 4 || c.Cycles != 4 {
+		t.Fatalf("expected breakpoint at PC=4 after 4 cycles, but got reason=%d, PC=%d, cycles=%d", stop.Reason, c.PC, c.Cycles)
+	}
+	if err := d.Delete("LOOP"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := d.Watch("R2"); err != nil {
+		t.Fatal(err)
+	}
+	stop := d.Continue(1000)
+	expected := Stop{WATCHPOINT_STOP, 2, 0, 4}
+	if stop != expected || c.PC != 14 {
+		t.Fatalf("expected=%+v at PC=14, but got=%+v at PC=%d", expected, stop, c.PC)
+	}
+
+	// stepping back restores registers and the RAM cell written
+	if undone := d.Back(1); undone != 1 || c.PC != 13 || c.RAM[2] != 0 {
+		t.Fatalf("expected PC=13, RAM[2]=0, but got undone=%d, PC=%d, RAM[2]=%d", undone, c.PC, c.RAM[2])
+	}
+
+	if err := d.Unwatch("R2"); err != nil {
+		t.Fatal(err)
+	}
+	if stop := d.Continue(1000); stop.Reason != HALT_STOP || c.RAM[2] != 12 {
+		t.Fatalf("expected halt with RAM[2]=12, but got reason=%d, RAM[2]=%d", stop.Reason, c.RAM[2])
+	}
+
+	cycles := int(c.Cycles)
+	if undone := d.Back(cycles + 10); undone != cycles || c.PC != 0 || c.Cycles != 0 || c.RAM[2] != 0 || c.RAM[16] != 0 {
+		t.Fatalf("expected start of program, but got undone=%d, PC=%d, cycles=%d, RAM[2]=%d, RAM[16]=%d",
+			undone, c.PC, c.Cycles, c.RAM[2], c.RAM[16])
+	}
+
+	// trace is limited to TraceSize instructions
+	d.TraceSize = 3
+	d.Step(10)
+	if undone := d.Back(10); undone != 3 || c.Cycles != 7 {
+		t.Fatalf("expected 3 instructions undone, but got undone=%d, cycles=%d", undone, c.Cycles)
+	}
+}
+
+func TestInteract(t *testing.T) {
+	d := load(t, multPath)
+	session := "set R0 2\nset R1 3\nbreak :27\nc\n\nregs\nstack 1\nback\nlist\ninfo\nx R2\nbreak nowhere\nq\nstep\n"
+	var out bytes.Buffer
+	if err := d.Interact(strings.NewReader(session), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"breakpoint at LOOP+9 (Mult.asm:27)",
+		"breakpoint :27\n=> LOOP+9 (Mult.asm:27)\tM=D+M",
+		"A=2 D=3 PC=13 M=RAM[2]=3 time=27",
+		"SP=2 LCL=3 ARG=3 THIS=0 THAT=0\n",
+		"=> LOOP+8 (Mult.asm:26)\t@R2",
+		"=>    26      @R2\n  *   27      M=D+M",
+		"breakpoint LOOP+9 (Mult.asm:27)\n",
+		"RAM[2]\t3\n",
+		"error: nowhere is not a label, ROM address or source line",
+	}
+	for _, s := range expected {
+		if !strings.Contains(out.String(), s) {
+			t.Fatalf("expected output to contain %q, but got=\n%v", s, out.String())
+		}
+	}
+	if d.Computer.PC != 12 {
+		t.Fatalf("expected commands after quit to be ignored, but got PC=%d", d.Computer.PC)
+	}
+}
@@ -0,0 +1,295 @@
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ishwar00/HackAssembler/emulator"
+)
+
+const help = `commands, LOC is a label, ROM address or FILE:LINE, CELL is a RAM address,
+predefined symbol or variable:
+  break LOC       (b)   stop when PC reaches LOC
+  delete LOC      (d)   remove breakpoint
+  watch CELL      (w)   stop when value of CELL changes
+  unwatch CELL          remove watchpoint
+  info            (i)   list breakpoints and watchpoints
+  step [N]        (s)   execute N instructions, default 1
+  back [N]        (bs)  step back N instructions, default 1
+  continue        (c)   run until breakpoint, watchpoint or halt
+  regs            (r)   show registers
+  ram CELL [N]    (x)   show N RAM cells from CELL, default 1
+  stack [N]             show stack pointers and top N stack values, default 8
+  list [LOC]      (l)   show source around LOC, default PC
+  set CELL VALUE        set A, D, PC or RAM cell
+  reset                 start program again, RAM is kept
+  quit            (q)   exit
+an empty line repeats the last command
+`
+
+// first RAM address of stack, where SP points at start of VM program
+const STACK_BASE = 256
+
+// Interact reads commands from in, one per line, and writes their results to
+// out until quit or end of in.
+func (d *Debugger) Interact(in io.Reader, out io.Writer) error {
+	bw := bufio.NewWriter(out)
+	defer bw.Flush()
+	scanner := bufio.NewScanner(in)
+	last := ""
+
+	fmt.Fprintf(bw, "%v: %d instructions, type help for commands\n", d.Program.Name, len(d.Program.Words))
+	d.printPosition(bw)
+	for {
+		fmt.Fprint(bw, "(hackdbg) ")
+		bw.Flush()
+		if !scanner.Scan() {
+			fmt.Fprintln(bw)
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			line = last
+		}
+		if line == "" {
+			continue
+		}
+		last = line
+
+		fields := strings.Fields(line)
+		if fields[0] == "quit" || fields[0] == "q" {
+			return nil
+		}
+		if err := d.exec(bw, fields[0], fields[1:]); err != nil {
+			fmt.Fprintf(bw, "error: %v\n", err)
+		}
+	}
+}
+
+func (d *Debugger) exec(w io.Writer, name string, args []string) error {
+	c := d.Computer
+	switch name {
+	case "break", "b":
+		if len(args) != 1 {
+			return fmt.Errorf("break expects a location")
+		}
+		address, err := d.Break(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "breakpoint at %v\n", d.Where(address))
+
+	case "delete", "d":
+		if len(args) != 1 {
+			return fmt.Errorf("delete expects a location")
+		}
+		return d.Delete(args[0])
+
+	case "watch", "w":
+		if len(args) != 1 {
+			return fmt.Errorf("watch expects a RAM cell")
+		}
+		address, err := d.Watch(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "watching RAM[%d] = %d\n", address, int16(c.RAM[address]))
+
+	case "unwatch":
+		if len(args) != 1 {
+			return fmt.Errorf("unwatch expects a RAM cell")
+		}
+		return d.Unwatch(args[0])
+
+	case "info", "i":
+		for _, address := range d.Breakpoints() {
+			fmt.Fprintf(w, "breakpoint %v\n", d.Where(address))
+		}
+		for _, address := range d.Watchpoints() {
+			fmt.Fprintf(w, "watchpoint %v = RAM[%d] = %d\n", d.watchpoints[address], address, int16(c.RAM[address]))
+		}
+
+	case "step", "s":
+		n, err := count(args, 1)
+		if err != nil {
+			return err
+		}
+		d.printStop(w, d.Step(n))
+
+	case "back", "bs":
+		n, err := count(args, 1)
+		if err != nil {
+			return err
+		}
+		if undone := d.Back(n); undone < n {
+			fmt.Fprintf(w, "stepped back %d instructions, trace does not reach further\n", undone)
+		}
+		d.printPosition(w)
+
+	case "continue", "c":
+		if len(args) != 0 {
+			return fmt.Errorf("continue expects no arguments")
+		}
+		d.printStop(w, d.Continue(d.MaxCycles))
+
+	case "regs", "r":
+		m := c.A & (emulator.RAM_SIZE - 1)
+		fmt.Fprintf(w, "A=%d D=%d PC=%d M=RAM[%d]=%d time=%d\n", int16(c.A), int16(c.D), c.PC, m, int16(c.RAM[m]), c.Cycles)
+
+	case "ram", "x":
+		if len(args) < 1 || len(args) > 2 {
+			return fmt.Errorf("ram expects a RAM cell and optionally number of cells")
+		}
+		address, err := d.ResolveRAM(args[0])
+		if err != nil {
+			return err
+		}
+		n, err := count(args[1:], 1)
+		if err != nil {
+			return err
+		}
+		for i := address; i < address+n && i < emulator.RAM_SIZE; i++ {
+			fmt.Fprintf(w, "RAM[%d]\t%d\n", i, int16(c.RAM[i]))
+		}
+
+	case "stack":
+		n, err := count(args, 8)
+		if err != nil {
+			return err
+		}
+		sp := int(c.RAM[0] & (emulator.RAM_SIZE - 1))
+		fmt.Fprintf(w, "SP=%d LCL=%d ARG=%d THIS=%d THAT=%d\n",
+			int16(c.RAM[0]), int16(c.RAM[1]), int16(c.RAM[2]), int16(c.RAM[3]), int16(c.RAM[4]))
+		for address := sp - 1; address >= STACK_BASE && address >= sp-n; address-- {
+			fmt.Fprintf(w, "RAM[%d]\t%d\n", address, int16(c.RAM[address]))
+		}
+
+	case "list", "l":
+		address := c.PC
+		if len(args) == 1 {
+			var err error
+			if address, err = d.ResolveROM(args[0]); err != nil {
+				return err
+			}
+		} else if len(args) > 1 {
+			return fmt.Errorf("list expects a location")
+		}
+		return d.list(w, address)
+
+	case "set":
+		if len(args) != 2 {
+			return fmt.Errorf("set expects a register or RAM cell and a value")
+		}
+		value, err := parseInt(args[1], 32)
+		if err != nil || value < -32768 || value > 65535 {
+			return fmt.Errorf("%v is not a 16 bit value", args[1])
+		}
+		switch args[0] {
+		case "A":
+			c.A = uint16(value)
+		case "D":
+			c.D = uint16(value)
+		case "PC":
+			c.PC = uint16(value)
+		default:
+			address, err := d.ResolveRAM(args[0])
+			if err != nil {
+				return err
+			}
+			c.RAM[address] = uint16(value)
+		}
+
+	case "reset":
+		d.Reset()
+		d.printPosition(w)
+
+	case "help", "h":
+		fmt.Fprint(w, help)
+
+	default:
+		return fmt.Errorf("unknown command %v, type help for commands", name)
+	}
+	return nil
+}
+
+func (d *Debugger) printStop(w io.Writer, stop Stop) {
+	switch stop.Reason {
+	case BREAKPOINT_STOP:
+		fmt.Fprintf(w, "breakpoint %v\n", d.breakpoints[d.Computer.PC])
+	case WATCHPOINT_STOP:
+		fmt.Fprintf(w, "watchpoint %v: RAM[%d] %d -> %d\n",
+			d.watchpoints[stop.Address], stop.Address, int16(stop.Old), int16(stop.New))
+	case HALT_STOP:
+		fmt.Fprintf(w, "program halted after %d cycles\n", d.Computer.Cycles)
+	case LIMIT_STOP:
+		fmt.Fprintf(w, "stopped after %d cycles without reaching a breakpoint\n", d.MaxCycles)
+	}
+	d.printPosition(w)
+}
+
+// => LOOP+2 (Mult.asm:14)	D=M
+func (d *Debugger) printPosition(w io.Writer) {
+	pc := d.Computer.PC
+	fmt.Fprintf(w, "=> %v\t%v\n", d.Where(pc), d.Instruction(pc))
+}
+
+// shows source lines around instruction at address, current line is marked
+// with => and lines with breakpoints with *
+func (d *Debugger) list(w io.Writer, address uint16) error {
+	if int(address) >= len(d.Program.Instrs) {
+		return fmt.Errorf("ROM address %d is not in program", address)
+	}
+	instrInfo := d.Program.Instrs[address]
+	source := d.Program.Source[instrInfo.InFile]
+
+	// lines of file with breakpoints, and line of PC if it is in file
+	breakAt, pcAt := map[int]bool{}, 0
+	for _, bp := range d.Breakpoints() {
+		if int(bp) >= len(d.Program.Instrs) {
+			continue
+		}
+		if info := d.Program.Instrs[bp]; info.InFile == instrInfo.InFile {
+			breakAt[info.AtLine] = true
+		}
+	}
+	if int(d.Computer.PC) < len(d.Program.Instrs) {
+		if info := d.Program.Instrs[d.Computer.PC]; info.InFile == instrInfo.InFile {
+			pcAt = info.AtLine
+		}
+	}
+
+	from, to := instrInfo.AtLine-5, instrInfo.AtLine+5
+	for line := from; line <= to; line++ {
+		if line < 1 || line > len(source) {
+			continue
+		}
+		mark := "  "
+		if line == pcAt {
+			mark = "=>"
+		}
+		bp := " "
+		if breakAt[line] {
+			bp = "*"
+		}
+		fmt.Fprintf(w, "%v%v%5d  %v\n", mark, bp, line, strings.TrimRight(source[line-1], " \t\r"))
+	}
+	return nil
+}
+
+// optional count argument, def if it is not given.
+func count(args []string, def int) (int, error) {
+	if len(args) == 0 {
+		return def, nil
+	}
+	if len(args) > 1 {
+		return 0, fmt.Errorf("expected a count, but got %v", strings.Join(args, " "))
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%v is not a positive count", args[0])
+	}
+	return n, nil
+}
@@ -0,0 +1,141 @@
+package disassembler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/ishwar00/HackAssembler/assembler"
+)
+
+// Options changes how Disassemble writes the assembly.
+type Options struct {
+	// appends ROM address of every instruction as a comment
+	Addresses bool
+
+	// write only documented mnemonics, as assembler does in strict mode,
+	// instructions with other comp bits have raw comp %Bacccccc and a
+	// comment, they assemble back outside of strict mode
+	Strict bool
+}
+
+const (
+	screen   = 16384
+	keyboard = 24576
+)
+
+// Disassemble turns machine code back into Hack assembly, words[i] is the
+// instruction stored at ROM address i. Assembling returned lines gives back
+// words, except C instructions with bit 14 or 13 clear, which no assembly can
+// write, CPU ignores these bits, so they are written as the instruction with
+// both bits set and a comment saying so.
+//
+// Addresses loaded into A right before a jump are jump targets, they are
+// written as @L<addr> and label (L<addr>) is declared at <addr>. SCREEN and
+// KBD are always written symbolically, addresses 0 to 15 are written as R0-R15
+// when next instruction accesses memory through them.
+func Disassemble(words []uint16, opts Options) []string {
+	var code assembler.Code
+	code.Initialize()
+
+	// key: ROM address, value: true if address is target of a jump
+	isTarget := map[int]bool{}
+	// key: ROM address of A instruction, value: true if it loads a jump target
+	loadsTarget := map[int]bool{}
+	for address, word := range words {
+		if isC(word) && word&0b111 != 0 && address > 0 && !isC(words[address-1]) {
+			target := int(words[address-1])
+			if target <= len(words) {
+				isTarget[target] = true
+				loadsTarget[address-1] = true
+			}
+		}
+	}
+
+	lines := []string{}
+	for address, word := range words {
+		if isTarget[address] {
+			lines = append(lines, fmt.Sprintf("(L%d)", address))
+		}
+
+		var instr string
+		if isC(word) {
+			instr = cInstruction(code, word, opts.Strict)
+		} else {
+			accessesMemory := address+1 < len(words) && isC(words[address+1]) && usesM(words[address+1])
+			instr = aInstruction(word, loadsTarget[address], accessesMemory)
+		}
+
+		if opts.Addresses {
+			lines = append(lines, fmt.Sprintf("\t%-16s // %d", instr, address))
+		} else {
+			lines = append(lines, "\t"+instr)
+		}
+	}
+	if isTarget[len(words)] {
+		lines = append(lines, fmt.Sprintf("(L%d)", len(words)))
+	}
+	return lines
+}
+
+// writes disassembly of words into w, one line per instruction or label.
+func Write(w io.Writer, words []uint16, opts Options) error {
+	bw := bufio.NewWriter(w)
+	for _, line := range Disassemble(words, opts) {
+		bw.WriteString(line + "\n")
+	}
+	return bw.Flush()
+}
+
+func isC(word uint16) bool {
+	return word&0x8000 != 0
+}
+
+// true if C instruction reads M in comp or writes M in dest
+func usesM(word uint16) bool {
+	return word&0x1000 != 0 || word&0b001000 != 0
+}
+
+func aInstruction(word uint16, loadsTarget bool, accessesMemory bool) string {
+	switch {
+	case loadsTarget:
+		return fmt.Sprintf("@L%d", word)
+	case word == screen:
+		return "@SCREEN"
+	case word == keyboard:
+		return "@KBD"
+	case word <= 15 && accessesMemory:
+		return fmt.Sprintf("@R%d", word)
+	default:
+		return fmt.Sprintf("@%d", word)
+	}
+}
+
+func cInstruction(code assembler.Code, word uint16, strict bool) string {
+	bits := fmt.Sprintf("%016b", word)
+	comp, documented := code.CompMnemonic(bits[3:10])
+	switch {
+	case strict && !documented:
+		comp = "%B" + bits[3:10]
+	case !strict:
+		comp = code.ExtendedCompMnemonic(bits[3:10])
+	}
+	// every dest and jump bits have a mnemonic
+	dest, _ := code.DestMnemonic(bits[10:13])
+	jump, _ := code.JumpMnemonic(bits[13:16])
+
+	instr := comp
+	if dest != "" {
+		instr = dest + "=" + instr
+	}
+	if jump != "" {
+		instr = instr + ";" + jump
+	}
+	if strict && !documented {
+		instr = fmt.Sprintf("%v // unknown instruction %v, comp is not documented", instr, bits)
+	}
+	if bits[1:3] != "11" {
+		instr = fmt.Sprintf("%v // bits 14 and 13 of %v are not set", instr, bits)
+	}
+	return instr
+}
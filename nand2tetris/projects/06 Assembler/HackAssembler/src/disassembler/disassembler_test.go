@@ -0,0 +1,145 @@
+package disassembler
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ishwar00/HackAssembler/assembler"
+	"github.com/ishwar00/HackAssembler/formats"
+)
+
+func TestDisassemble(t *testing.T) {
+	input := `
+	@R2
+	M=0
+(LOOP)
+	@16384
+	D=A
+	@KBD
+	D=M
+	@LOOP
+	D;JEQ
+	@3
+	D=A
+	@END
+	0;JMP
+(END)
+`
+	expected := []string{
+		"\t@R2",
+		"\tM=0",
+		"(L2)",
+		"\t@SCREEN",
+		"\tD=A",
+		"\t@KBD",
+		"\tD=M",
+		"\t@L2",
+		"\tD;JEQ",
+		"\t@3",
+		"\tD=A",
+		"\t@L12",
+		"\t0;JMP",
+		"(L12)",
+	}
+
+	program, err := assembler.Assemble(strings.NewReader(input), "test.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := Disassemble(program.Words, Options{})
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected=\n%v\nbut got=\n%v", strings.Join(expected, "\n"), strings.Join(lines, "\n"))
+	}
+}
+
+func TestUnknownInstruction(t *testing.T) {
+	// unknown instruction keeps its word, so later addresses do not move
+	words := []uint16{0b1110000001000000, 0b1110101010000111}
+	lines := Disassemble(words, Options{Addresses: true, Strict: true})
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "\t%B0000001") || !strings.Contains(lines[0], "unknown instruction 1110000001000000") {
+		t.Fatalf("expected unknown instruction, but got=%q", lines)
+	}
+	program, err := assembler.Assemble(strings.NewReader(strings.Join(lines, "\n")), "test.asm")
+	if err != nil || fmt.Sprint(program.Words) != fmt.Sprint(words) {
+		t.Fatalf("expected=%v, but got=%v, %v", words, program.Words, err)
+	}
+
+	// extended mnemonics, a bare comp too, assemble back
+	words = []uint16{0b1110000001000000, 0b1111001100001000}
+	lines = Disassemble(words, Options{})
+	if strings.Join(lines, "\n") != "\t!(D&A)\n\tM=%B1001100" {
+		t.Fatalf("expected extended mnemonics, but got=%q", lines)
+	}
+	program, err = assembler.Assemble(strings.NewReader(strings.Join(lines, "\n")), "test.asm")
+	if err != nil || fmt.Sprint(program.Words) != fmt.Sprint(words) {
+		t.Fatalf("expected=%v, but got=%v, %v", words, program.Words, err)
+	}
+}
+
+// assembly can not clear bits 14 and 13 of C instruction, they come back set
+func TestUnusedBits(t *testing.T) {
+	words := []uint16{0b1000110000010000, 0b1010101010000111, 0b1100110000010000}
+	for i, word := range words {
+		lines := Disassemble([]uint16{word}, Options{})
+		if len(lines) != 1 || !strings.Contains(lines[0], fmt.Sprintf("// bits 14 and 13 of %016b are not set", word)) {
+			t.Fatalf("tests[%d]: expected comment on bits 14 and 13, but got=%q", i, lines)
+		}
+		program, err := assembler.Assemble(strings.NewReader(lines[0]), "test.asm")
+		if err != nil {
+			t.Fatalf("tests[%d]: %v", i, err)
+		}
+		if expected := word | 0b0110000000000000; len(program.Words) != 1 || program.Words[0] != expected {
+			t.Fatalf("tests[%d]: expected=%016b, but got=%016b", i, expected, program.Words)
+		}
+	}
+}
+
+// every comp, with neither dest nor jump, disassembles into a bare comp that
+// assembles back into it
+func TestRoundTripComps(t *testing.T) {
+	for comp := uint16(0); comp < 1<<7; comp++ {
+		word := 0b111<<13 | comp<<6
+		lines := Disassemble([]uint16{word}, Options{})
+		program, err := assembler.Assemble(strings.NewReader(strings.Join(lines, "\n")), "test.asm")
+		if err != nil {
+			t.Fatalf("%q: %v", lines, err)
+		}
+		if len(program.Words) != 1 || program.Words[0] != word {
+			t.Fatalf("%q: expected=%016b, but got=%016b", lines, word, program.Words)
+		}
+	}
+}
+
+// machine code in projects/05 disassembles into assembly that assembles back into it
+func TestRoundTrip(t *testing.T) {
+	tests := []string{
+		"../../../../05 Computer Architecture/Add.hack",
+		"../../../../05 Computer Architecture/Max.hack",
+		"../../../../05 Computer Architecture/Rect.hack",
+		"../../../pong/Pong.hack",
+	}
+
+	for _, hackPath := range tests {
+		file, err := os.Open(hackPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		words, err := formats.Hack{}.Read(file)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		source := strings.Join(Disassemble(words, Options{Addresses: true}), "\n")
+		program, err := assembler.Assemble(strings.NewReader(source), hackPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(program.Words) != fmt.Sprint(words) {
+			t.Fatalf("%v: reassembled disassembly does not match", hackPath)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Package emulator executes Hack machine code, it is the Hack computer of
+// the course: CPU with A, D and PC registers, 32K ROM holding the program and
+// 32K RAM with memory maps of the screen and the keyboard.
+package emulator
+
+import "fmt"
+
+const (
+	ROM_SIZE = 1 << 15
+	RAM_SIZE = 1 << 15
+
+	SCREEN      = 16384 // first word of screen memory map
+	SCREEN_SIZE = 8192  // 256 rows of 32 words, 512 pixels each
+	KBD         = 24576 // key code of key being pressed, 0 if none
+)
+
+// Computer is the state of Hack computer, it changes by executing
+// one instruction after another with Step or Run.
+type Computer struct {
+	A, D, PC uint16
+	ROM      [ROM_SIZE]uint16
+	RAM      [RAM_SIZE]uint16
+
+	// number of instructions executed since Reset, Hack executes one
+	// instruction per clock cycle
+	Cycles uint64
+}
+
+// Load puts program into ROM, words[i] at address i, rest of ROM is cleared,
+// and resets the computer. RAM is kept, as memory is not cleared by reset.
+func (c *Computer) Load(words []uint16) error {
+	if len(words) > ROM_SIZE {
+		return fmt.Errorf("program has %d instructions, but ROM holds %d", len(words), ROM_SIZE)
+	}
+	n := copy(c.ROM[:], words)
+	for i := n; i < ROM_SIZE; i++ {
+		c.ROM[i] = 0
+	}
+	c.Reset()
+	return nil
+}
+
+// Reset starts program again from ROM address 0.
+func (c *Computer) Reset() {
+	c.A, c.D, c.PC = 0, 0, 0
+	c.Cycles = 0
+}
+
+// Step executes the instruction at PC.
+func (c *Computer) Step() {
+	instr := c.ROM[c.PC&(ROM_SIZE-1)]
+	c.Cycles++
+	if instr&0x8000 == 0 {
+		c.A = instr // A instruction
+		c.PC++
+		return
+	}
+
+	// C instruction 111a cccc ccdd djjj, registers and memory are written at
+	// the end of cycle, so M and jump target are given by A as it was before
+	// the instruction, eg: AM=M-1;JMP jumps to old A, as the hardware does
+	a := c.A
+	address := a & (RAM_SIZE - 1) // address bus of RAM has 15 bits
+	y := a
+	if instr&0x1000 != 0 {
+		y = c.RAM[address]
+	}
+	out := ALU(c.D, y, instr>>6)
+
+	if instr&0x0008 != 0 {
+		c.RAM[address] = out
+	}
+	if instr&0x0020 != 0 {
+		c.A = out
+	}
+	if instr&0x0010 != 0 {
+		c.D = out
+	}
+	if Jumps(out, instr) {
+		c.PC = a
+	} else {
+		c.PC++
+	}
+}
+
+// Run executes instructions until the program halts, see Halted, or
+// maxCycles instructions are executed, 0 means no limit. It returns
+// number of instructions executed and whether the program halted.
+func (c *Computer) Run(maxCycles uint64) (cycles uint64, halted bool) {
+	for maxCycles == 0 || cycles < maxCycles {
+		if c.Halted() {
+			return cycles, true
+		}
+		c.Step()
+		cycles++
+	}
+	return cycles, c.Halted()
+}
+
+// Halted is true if PC is at the loop programs end with, it jumps to itself
+// forever:
+//
+//	(END)
+//	@END
+//	0;JMP
+func (c *Computer) Halted() bool {
+	pc := c.PC & (ROM_SIZE - 1)
+	if c.ROM[pc] != pc || pc+1 >= ROM_SIZE {
+		return false
+	}
+	next := c.ROM[pc+1]
+	// C instruction with no dest, whose jump is taken whatever ALU computes
+	return next&0x8000 != 0 && next&0x0038 == 0 && next&0x0007 == 0x0007
+}
+
+// ALU computes comp of C instruction, bits are zx nx zy ny f no in bits 5..0
+// of control, x is D and y is A or M.
+func ALU(x, y, control uint16) uint16 {
+	if control&0x20 != 0 { // zx
+		x = 0
+	}
+	if control&0x10 != 0 { // nx
+		x = ^x
+	}
+	if control&0x08 != 0 { // zy
+		y = 0
+	}
+	if control&0x04 != 0 { // ny
+		y = ^y
+	}
+	out := x & y
+	if control&0x02 != 0 { // f
+		out = x + y
+	}
+	if control&0x01 != 0 { // no
+		out = ^out
+	}
+	return out
+}
+
+// Jumps is true if jump bits of C instruction instr are satisfied by out.
+func Jumps(out, instr uint16) bool {
+	negative, zero := int16(out) < 0, out == 0
+	positive := !negative && !zero
+	return instr&0x0004 != 0 && negative ||
+		instr&0x0002 != 0 && zero ||
+		instr&0x0001 != 0 && positive
+}
@@ -0,0 +1,137 @@
+package emulator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ishwar00/HackAssembler/assembler"
+	"github.com/ishwar00/HackAssembler/files"
+)
+
+// assembles source and loads it into a new computer
+func load(t *testing.T, source string) *Computer {
+	t.Helper()
+	program, err := assembler.Assemble(strings.NewReader(source), "test.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newComputer(t, program.Words)
+}
+
+// same as load, but program is read from file at path
+func loadFile(t *testing.T, path string) *Computer {
+	t.Helper()
+	program, err := files.ReadProgram(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newComputer(t, program.Words)
+}
+
+func newComputer(t *testing.T, words []uint16) *Computer {
+	t.Helper()
+	computer := new(Computer)
+	if err := computer.Load(words); err != nil {
+		t.Fatal(err)
+	}
+	return computer
+}
+
+func TestComp(t *testing.T) {
+	// D = 5, A = 3, M = RAM[3] = 9
+	tests := []struct {
+		comp     string
+		expected int16
+	}{
+		{"0", 0}, {"1", 1}, {"-1", -1}, {"D", 5}, {"A", 3}, {"!D", ^5}, {"!A", ^3},
+		{"-D", -5}, {"-A", -3}, {"D+1", 6}, {"A+1", 4}, {"D-1", 4}, {"A-1", 2},
+		{"D+A", 8}, {"D-A", 2}, {"A-D", -2}, {"D&A", 1}, {"D|A", 7},
+		{"M", 9}, {"!M", ^9}, {"-M", -9}, {"M+1", 10}, {"M-1", 8},
+		{"D+M", 14}, {"D-M", -4}, {"M-D", 4}, {"D&M", 1}, {"D|M", 13},
+		{"D&!A", 4}, {"-2", -2}, // extended ALU functions
+	}
+
+	for i, tt := range tests {
+		computer := load(t, "@3\nD="+tt.comp+"\n")
+		computer.D, computer.RAM[3] = 5, 9
+		computer.Step()
+		computer.Step()
+		if int16(computer.D) != tt.expected {
+			t.Fatalf("tests[%d]: %v: expected=%d, but got=%d", i, tt.comp, tt.expected, int16(computer.D))
+		}
+	}
+}
+
+func TestJump(t *testing.T) {
+	jumps := []string{"JGT", "JEQ", "JGE", "JLT", "JNE", "JLE", "JMP"}
+	expected := map[int16]string{ // jumps taken for value of D
+		-1: "JLT JNE JLE JMP",
+		0:  "JEQ JGE JLE JMP",
+		1:  "JGT JGE JNE JMP",
+	}
+
+	for value, taken := range expected {
+		for _, jump := range jumps {
+			computer := load(t, "@100\nD;"+jump+"\n")
+			computer.D = uint16(value)
+			computer.Step()
+			computer.Step()
+			jumped := computer.PC == 100
+			if jumped != strings.Contains(taken, jump) {
+				t.Fatalf("D=%d: %v: expected jump=%v, but got PC=%d", value, jump, !jumped, computer.PC)
+			}
+		}
+	}
+
+	// M and jump target are given by A before the instruction
+	computer := load(t, "@7\nAM=M-1;JMP\n")
+	computer.RAM[7] = 20
+	computer.Step()
+	computer.Step()
+	if computer.PC != 7 || computer.A != 19 || computer.RAM[7] != 19 {
+		t.Fatalf("expected PC=7, A=19, RAM[7]=19, but got PC=%d, A=%d, RAM[7]=%d", computer.PC, computer.A, computer.RAM[7])
+	}
+}
+
+func TestRun(t *testing.T) {
+	computer := load(t, "@3\nD=A\n(END)\n@END\n0;JMP\n")
+	cycles, halted := computer.Run(100)
+	if !halted || cycles != 2 || computer.PC != 2 {
+		t.Fatalf("expected halt after 2 cycles at PC=2, but got halted=%v, cycles=%d, PC=%d", halted, cycles, computer.PC)
+	}
+
+	computer = load(t, "(LOOP)\n@LOOP\nD;JEQ\n")
+	cycles, halted = computer.Run(100)
+	if halted || cycles != 100 {
+		t.Fatalf("expected conditional loop to run 100 cycles, but got halted=%v, cycles=%d", halted, cycles)
+	}
+}
+
+// programs of projects 04 and 06 assembled by the assembler compute what they should.
+func TestRunPrograms(t *testing.T) {
+	tests := []struct {
+		asmPath  string
+		set      map[int]int16
+		expected map[int]int16
+	}{
+		{"../../../max/Max.asm", map[int]int16{0: 3, 1: 17}, map[int]int16{2: 17}},
+		{"../../../max/MaxL.asm", map[int]int16{0: -4, 1: -9}, map[int]int16{2: -4}},
+		{"../../../../04 Machine Language/mult/Mult.asm", map[int]int16{0: 6, 1: 7}, map[int]int16{2: 42}},
+		{"../../../rect/Rect.asm", map[int]int16{0: 3}, map[int]int16{SCREEN: -1, SCREEN + 64: -1, SCREEN + 96: 0}},
+	}
+
+	for _, tt := range tests {
+		computer := loadFile(t, tt.asmPath)
+		for address, value := range tt.set {
+			computer.RAM[address] = uint16(value)
+		}
+		if _, halted := computer.Run(1000000); !halted {
+			t.Fatalf("%v: expected program to halt", tt.asmPath)
+		}
+		for address, value := range tt.expected {
+			if int16(computer.RAM[address]) != value {
+				t.Fatalf("%v: RAM[%d]: expected=%d, but got=%d", tt.asmPath, address, value, int16(computer.RAM[address]))
+			}
+		}
+	}
+}
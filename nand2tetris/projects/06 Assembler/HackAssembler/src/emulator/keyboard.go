@@ -0,0 +1,163 @@
+package emulator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// key codes of Hack keyboard for keys which are not printable characters,
+// printable characters have their ASCII code
+const (
+	NEWLINE_KEY   = 128
+	BACKSPACE_KEY = 129
+	LEFT_KEY      = 130
+	UP_KEY        = 131
+	RIGHT_KEY     = 132
+	DOWN_KEY      = 133
+	HOME_KEY      = 134
+	END_KEY       = 135
+	PAGE_UP_KEY   = 136
+	PAGE_DOWN_KEY = 137
+	INSERT_KEY    = 138
+	DELETE_KEY    = 139
+	ESC_KEY       = 140
+	F1_KEY        = 141 // F1 to F12 are 141 to 152
+)
+
+// names of keys in key scripts
+var keyNames = map[string]uint16{
+	"NONE": 0, "SPACE": ' ', "NEWLINE": NEWLINE_KEY, "ENTER": NEWLINE_KEY,
+	"BACKSPACE": BACKSPACE_KEY, "LEFT": LEFT_KEY, "UP": UP_KEY, "RIGHT": RIGHT_KEY,
+	"DOWN": DOWN_KEY, "HOME": HOME_KEY, "END": END_KEY, "PAGEUP": PAGE_UP_KEY,
+	"PAGEDOWN": PAGE_DOWN_KEY, "INSERT": INSERT_KEY, "DELETE": DELETE_KEY, "ESC": ESC_KEY,
+}
+
+// escape sequences of terminals for keys, without leading ESC
+var escapeSequences = map[string]uint16{
+	"[A": UP_KEY, "[B": DOWN_KEY, "[C": RIGHT_KEY, "[D": LEFT_KEY,
+	"[H": HOME_KEY, "[F": END_KEY, "OH": HOME_KEY, "OF": END_KEY, "[1~": HOME_KEY, "[4~": END_KEY,
+	"[2~": INSERT_KEY, "[3~": DELETE_KEY, "[5~": PAGE_UP_KEY, "[6~": PAGE_DOWN_KEY,
+	"OP": F1_KEY, "OQ": F1_KEY + 1, "OR": F1_KEY + 2, "OS": F1_KEY + 3,
+	"[15~": F1_KEY + 4, "[17~": F1_KEY + 5, "[18~": F1_KEY + 6, "[19~": F1_KEY + 7,
+	"[20~": F1_KEY + 8, "[21~": F1_KEY + 9, "[23~": F1_KEY + 10, "[24~": F1_KEY + 11,
+}
+
+// DecodeKey returns Hack key code of first key in input read from terminal
+// and number of bytes it takes, code is 0 for a key Hack does not have.
+func DecodeKey(input []byte) (code uint16, n int) {
+	if len(input) == 0 {
+		return 0, 0
+	}
+	switch c := input[0]; {
+	case c == 0x1b:
+		for seq, code := range escapeSequences {
+			if strings.HasPrefix(string(input[1:]), seq) {
+				return code, 1 + len(seq)
+			}
+		}
+		if len(input) > 1 && (input[1] == '[' || input[1] == 'O') {
+			// unknown sequence, skip it up to its final byte
+			n := 2
+			for n < len(input) && (input[n] < 0x40 || input[n] > 0x7e) {
+				n++
+			}
+			return 0, min(n+1, len(input))
+		}
+		return ESC_KEY, 1
+	case c == '\r' || c == '\n':
+		return NEWLINE_KEY, 1
+	case c == 0x7f || c == 0x08:
+		return BACKSPACE_KEY, 1
+	case c >= ' ' && c <= '~':
+		return uint16(c), 1
+	}
+	return 0, 1
+}
+
+// KeyEvent sets key code of keyboard memory map at a cycle, code 0 releases
+// the key.
+type KeyEvent struct {
+	Cycle uint64
+	Code  uint16
+}
+
+// ParseKeys parses a key script, events separated by commas or white space
+// as CYCLE:KEY, where KEY is a printable character, a key name, eg: LEFT,
+// ENTER, F5 or SPACE, or NONE to release the key. Events are ordered by cycle.
+//
+//	0:RIGHT 50000:NONE 80000:q
+func ParseKeys(script string) ([]KeyEvent, error) {
+	events := []KeyEvent{}
+	fields := strings.FieldsFunc(script, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	for _, field := range fields {
+		cycle, key, ok := strings.Cut(field, ":")
+		n, err := strconv.ParseUint(cycle, 10, 64)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("malformed key event %q, expected CYCLE:KEY", field)
+		}
+		code, err := keyCode(key)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, KeyEvent{n, code})
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Cycle < events[j].Cycle })
+	return events, nil
+}
+
+func keyCode(key string) (uint16, error) {
+	if len(key) == 1 && key[0] >= '!' && key[0] <= '~' {
+		return uint16(key[0]), nil
+	}
+	name := strings.ToUpper(key)
+	if code, ok := keyNames[name]; ok {
+		return code, nil
+	}
+	if strings.HasPrefix(name, "F") {
+		if n, err := strconv.Atoi(name[1:]); err == nil && n >= 1 && n <= 12 {
+			return uint16(F1_KEY + n - 1), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown key %q", key)
+}
+
+// RunKeys runs program like Run, setting keyboard memory map to code of each
+// key event when Cycles reaches its cycle, events must be ordered by cycle.
+func (c *Computer) RunKeys(events []KeyEvent, maxCycles uint64) (cycles uint64, halted bool) {
+	script := KeyScript{Events: events}
+	return script.Run(c, maxCycles)
+}
+
+// KeyScript presses keys of its events while program runs, like RunKeys, but
+// it remembers events already pressed, so program can be run in parts, eg:
+// a frame at a time, without pressing them again.
+type KeyScript struct {
+	Events []KeyEvent // ordered by cycle
+	next   int        // first event not pressed yet
+}
+
+func (s *KeyScript) Run(c *Computer, maxCycles uint64) (cycles uint64, halted bool) {
+	for maxCycles == 0 || cycles < maxCycles {
+		for s.next < len(s.Events) && s.Events[s.next].Cycle <= c.Cycles {
+			c.RAM[KBD] = s.Events[s.next].Code
+			s.next++
+		}
+		if c.Halted() {
+			return cycles, true
+		}
+		c.Step()
+		cycles++
+	}
+	return cycles, c.Halted()
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,111 @@
+package emulator
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+const (
+	SCREEN_WIDTH  = 512
+	SCREEN_HEIGHT = 256
+)
+
+// ways to draw screen with characters
+const (
+	HALF_BLOCK_MODE = iota // ▀ ▄ █, a character is 1x2 pixels
+	BRAILLE_MODE           // ⣿, a character is 2x4 pixels
+)
+
+// Pixel is true if pixel at column x and row y is black, it is bit x%16 of
+// word SCREEN + 32*y + x/16, least significant bit is the leftmost pixel.
+func (c *Computer) Pixel(x, y int) bool {
+	word := c.RAM[SCREEN+32*y+x/16]
+	return word>>(x%16)&1 != 0
+}
+
+// Image returns screen as grayscale image, black pixels are black.
+func (c *Computer) Image() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, SCREEN_WIDTH, SCREEN_HEIGHT))
+	for y := 0; y < SCREEN_HEIGHT; y++ {
+		for x := 0; x < SCREEN_WIDTH; x++ {
+			if c.Pixel(x, y) {
+				img.SetGray(x, y, color.Gray{0})
+			} else {
+				img.SetGray(x, y, color.Gray{255})
+			}
+		}
+	}
+	return img
+}
+
+// WritePNG writes screen as PNG image.
+func (c *Computer) WritePNG(w io.Writer) error {
+	return png.Encode(w, c.Image())
+}
+
+// braille dot of pixel at column x%2 and row y%4 of a character
+var brailleDots = [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+
+// Render draws screen with characters in mode, black pixels are drawn, so
+// they look bright on a dark terminal. Every scale x scale block of pixels is
+// one dot, which is drawn if any pixel of block is black, so thin lines are
+// kept when screen is scaled down to fit the terminal.
+func (c *Computer) Render(w io.Writer, mode, scale int) error {
+	if scale < 1 {
+		return fmt.Errorf("scale %d is not a positive number", scale)
+	}
+	dot := func(x, y int) bool {
+		for j := y * scale; j < (y+1)*scale && j < SCREEN_HEIGHT; j++ {
+			for i := x * scale; i < (x+1)*scale && i < SCREEN_WIDTH; i++ {
+				if c.Pixel(i, j) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	width, height := (SCREEN_WIDTH+scale-1)/scale, (SCREEN_HEIGHT+scale-1)/scale
+
+	bw := bufio.NewWriter(w)
+	switch mode {
+	case HALF_BLOCK_MODE:
+		for y := 0; y < height; y += 2 {
+			for x := 0; x < width; x++ {
+				top, bottom := dot(x, y), y+1 < height && dot(x, y+1)
+				switch {
+				case top && bottom:
+					bw.WriteRune('█')
+				case top:
+					bw.WriteRune('▀')
+				case bottom:
+					bw.WriteRune('▄')
+				default:
+					bw.WriteRune(' ')
+				}
+			}
+			bw.WriteByte('\n')
+		}
+	case BRAILLE_MODE:
+		for y := 0; y < height; y += 4 {
+			for x := 0; x < width; x += 2 {
+				char := rune(0x2800)
+				for j := 0; j < 4 && y+j < height; j++ {
+					for i := 0; i < 2 && x+i < width; i++ {
+						if dot(x+i, y+j) {
+							char |= brailleDots[j][i]
+						}
+					}
+				}
+				bw.WriteRune(char)
+			}
+			bw.WriteByte('\n')
+		}
+	default:
+		return fmt.Errorf("unknown render mode %d", mode)
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,146 @@
+package emulator
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	computer := new(Computer)
+	computer.RAM[SCREEN] = 0x0003    // pixels (0,0) and (1,0)
+	computer.RAM[SCREEN+32] = 0x0001 // pixel (0,1)
+	computer.RAM[SCREEN+32*255+31] = 0x8000
+
+	if !computer.Pixel(1, 0) || computer.Pixel(2, 0) || !computer.Pixel(511, 255) {
+		t.Fatalf("expected pixels (1,0) and (511,255) to be black and (2,0) white")
+	}
+
+	tests := []struct {
+		mode, scale int
+		first, last string // first and last line of rendered screen
+	}{
+		{HALF_BLOCK_MODE, 1, "█▀" + strings.Repeat(" ", 510), strings.Repeat(" ", 511) + "▄"},
+		{BRAILLE_MODE, 1, "⠋" + strings.Repeat("⠀", 255), strings.Repeat("⠀", 255) + "⢀"},
+		{BRAILLE_MODE, 4, "⠁" + strings.Repeat("⠀", 63), strings.Repeat("⠀", 63) + "⢀"},
+	}
+
+	for i, tt := range tests {
+		var out bytes.Buffer
+		if err := computer.Render(&out, tt.mode, tt.scale); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		if lines[0] != tt.first || lines[len(lines)-1] != tt.last {
+			t.Fatalf("tests[%d]: expected=%q ... %q, but got=%q ... %q", i, tt.first, tt.last, lines[0], lines[len(lines)-1])
+		}
+	}
+
+	var out bytes.Buffer
+	if err := computer.WritePNG(&out); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, _, _, _ := img.At(1, 0).RGBA(); r != 0 {
+		t.Fatalf("expected pixel (1,0) of image to be black")
+	}
+	if r, _, _, _ := img.At(2, 0).RGBA(); r != 0xffff {
+		t.Fatalf("expected pixel (2,0) of image to be white")
+	}
+}
+
+func TestDecodeKey(t *testing.T) {
+	tests := []struct {
+		input string
+		code  uint16
+		n     int
+	}{
+		{"a", 'a', 1}, {" x", ' ', 1}, {"\r", NEWLINE_KEY, 1}, {"\x7f", BACKSPACE_KEY, 1},
+		{"\x1b[A", UP_KEY, 3}, {"\x1b[Cx", RIGHT_KEY, 3}, {"\x1bOP", F1_KEY, 3},
+		{"\x1b[24~", F1_KEY + 11, 5}, {"\x1b[3~", DELETE_KEY, 4}, {"\x1b", ESC_KEY, 1},
+		{"\x1b[99~a", 0, 5}, {"\x01", 0, 1},
+	}
+
+	for i, tt := range tests {
+		code, n := DecodeKey([]byte(tt.input))
+		if code != tt.code || n != tt.n {
+			t.Fatalf("tests[%d]: %q: expected=%d, %d, but got=%d, %d", i, tt.input, tt.code, tt.n, code, n)
+		}
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	events, err := ParseKeys("500:NONE, 0:right\n100:q 200:F5 300:space")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []KeyEvent{{0, RIGHT_KEY}, {100, 'q'}, {200, F1_KEY + 4}, {300, ' '}, {500, 0}}
+	if len(events) != len(expected) {
+		t.Fatalf("expected=%v, but got=%v", expected, events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Fatalf("expected=%v, but got=%v", expected, events)
+		}
+	}
+
+	for _, script := range []string{"10", "x:a", "10:F13", "10:shift"} {
+		if _, err := ParseKeys(script); err == nil {
+			t.Fatalf("%q: expected error", script)
+		}
+	}
+}
+
+// Fill of project 04 blackens the screen while a key is pressed.
+func TestRunKeys(t *testing.T) {
+	computer := loadFile(t, "../../../../04 Machine Language/fill/Fill.asm")
+	events, err := ParseKeys("300000:k 600000:NONE")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []bool{false, true, false} // bottom right pixel is black
+	for i, black := range expected {
+		computer.RunKeys(events, 300000)
+		if computer.Pixel(511, 255) != black || computer.Pixel(0, 0) != black {
+			t.Fatalf("cycle %d: expected black=%v", computer.Cycles, black)
+		}
+		if i == 1 && computer.RAM[KBD] != 'k' {
+			t.Fatalf("expected KBD=%d, but got=%d", 'k', computer.RAM[KBD])
+		}
+	}
+}
+
+// keys pressed live are not replaced by script events pressed in earlier parts
+func TestKeyScript(t *testing.T) {
+	computer := load(t, "(LOOP)\nD=D+1\n@LOOP\n0;JMP\n")
+	events, err := ParseKeys("0:a 10:b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := KeyScript{Events: events}
+
+	tests := []struct {
+		set      uint16 // pressed before part runs, 0 for none
+		expected uint16
+	}{
+		{0, 'b'},
+		{'x', 'x'},
+		{0, 'x'},
+	}
+	for i, tt := range tests {
+		if tt.set != 0 {
+			computer.RAM[KBD] = tt.set
+		}
+		if cycles, _ := script.Run(computer, 20); cycles != 20 {
+			t.Fatalf("tests[%d]: expected=20 cycles, but got=%d", i, cycles)
+		}
+		if computer.RAM[KBD] != tt.expected {
+			t.Fatalf("tests[%d]: expected KBD=%d, but got=%d", i, tt.expected, computer.RAM[KBD])
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package errhandler
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// same report layout as JackAnalyzer's errhandler, which is inspired from vlang
+// https://github.com/vlang/v/blob/6b0743bb07f8d003ff703dc48ae2120cd8bc152a/vlib/v/util/errors.v#L20
+// unlike JackAnalyzer, source is not read back from disk, assembler may not have
+// read it from a file at all, so it has to be registered with AddSource.
+
+// error_context_before - how many lines of source context to print before the pointer line
+// error_context_after - ^^^ same, but after
+const (
+	error_context_before = 2
+	error_context_after  = 2
+)
+
+type Error struct {
+	ErrMsg   string
+	OnLine   int // starts from 0
+	OnColumn int // starts from 0
+
+	// Length: length of text, pointed by pointer line
+	// starting from onColumn to onColumn + length
+	Length int
+	File   string
+
+	// warning does not stop the program from being translated,
+	// it is reported the same way, but labelled as warning
+	Warning bool
+}
+
+// filepath:line:col: error_message
+func (e *Error) format() string {
+	if e.Warning {
+		return fmt.Sprintf("%s:%d:%d: %s: %s",
+			e.File, e.OnLine+1, e.OnColumn+1, color.YellowString("warning"), e.ErrMsg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s",
+		e.File, e.OnLine+1, e.OnColumn+1, color.RedString("error"), e.ErrMsg)
+}
+
+type ErrHandler struct {
+	error_count   int
+	warning_count int
+	fileErrs      map[string][]Error  // key: fileName, value: slice of Error in file fileName
+	sources       map[string][]string // key: fileName, value: lines of file fileName
+}
+
+func (eh *ErrHandler) Add(errMsg Error) {
+	if eh.fileErrs == nil {
+		eh.fileErrs = make(map[string][]Error)
+	}
+	fileName := errMsg.File
+	eh.fileErrs[fileName] = append(eh.fileErrs[fileName], errMsg)
+	if errMsg.Warning {
+		eh.warning_count++
+	} else {
+		eh.error_count++
+	}
+}
+
+// registers source lines of fileName, they are printed around the errors as context.
+func (eh *ErrHandler) AddSource(fileName string, lines []string) {
+	if eh.sources == nil {
+		eh.sources = make(map[string][]string)
+	}
+	eh.sources[fileName] = lines
+}
+
+// Error renders every error, grouped by file and ordered by position,
+// so that ErrHandler can be returned as an error.
+func (eh *ErrHandler) Error() string {
+	var report strings.Builder
+
+	files := make([]string, 0, len(eh.fileErrs))
+	for file := range eh.fileErrs {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	tab := "    "
+	for _, file := range files {
+		errs := eh.fileErrs[file]
+		cmpr := func(i, j int) bool {
+			if errs[i].OnLine == errs[j].OnLine {
+				return errs[i].OnColumn < errs[j].OnColumn
+			}
+			return errs[i].OnLine < errs[j].OnLine
+		}
+		sort.SliceStable(errs, cmpr)
+		source := eh.sources[file]
+
+		for _, err := range errs {
+			report.WriteString(fmt.Sprintf("\n%s\n", err.format()))
+			if err.OnLine < 0 || err.OnLine >= len(source) {
+				continue // there is no source to show
+			}
+
+			bline := max(0, err.OnLine-error_context_before)
+			aline := min(len(source)-1, err.OnLine+error_context_after)
+			for onLine := bline; onLine <= aline; onLine++ {
+				sline := strings.ReplaceAll(source[onLine], "\t", tab)
+				report.WriteString(fmt.Sprintf("%5d | %s\n", onLine+1, sline))
+				if err.OnLine == onLine {
+					before := source[onLine][:min(err.OnColumn, len(source[onLine]))]
+					offset := strings.Repeat(" ", len(strings.ReplaceAll(before, "\t", tab)))
+					pointer_str := strings.Repeat("^", max(1, err.Length))
+					if err.Warning {
+						pointer_str = color.YellowString(pointer_str)
+					} else {
+						pointer_str = color.RedString(pointer_str)
+					}
+					report.WriteString(fmt.Sprintf("      | %s%s\n", offset, pointer_str))
+				}
+			}
+		}
+	}
+	return report.String()
+}
+
+// Errors returns every error and warning added, ordered by file, line and column.
+func (eh *ErrHandler) Errors() []Error {
+	errs := []Error{}
+	for _, fileErrs := range eh.fileErrs {
+		errs = append(errs, fileErrs...)
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].File != errs[j].File {
+			return errs[i].File < errs[j].File
+		}
+		if errs[i].OnLine != errs[j].OnLine {
+			return errs[i].OnLine < errs[j].OnLine
+		}
+		return errs[i].OnColumn < errs[j].OnColumn
+	})
+	return errs
+}
+
+func (eh *ErrHandler) ReportAll() {
+	os.Stdout.WriteString(eh.Error())
+}
+
+// number of errors added, warnings are not counted
+func (eh *ErrHandler) Error_count() int {
+	return eh.error_count
+}
+
+func (eh *ErrHandler) Warning_count() int {
+	return eh.warning_count
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
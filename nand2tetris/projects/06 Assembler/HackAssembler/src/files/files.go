@@ -0,0 +1,117 @@
+// Package files holds file handling shared by commands of HackAssembler.
+package files
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ishwar00/HackAssembler/assembler"
+	"github.com/ishwar00/HackAssembler/formats"
+)
+
+// path which stands for standard output in WriteFile
+const Stdout = "-"
+
+// writes file at path through write. Content is written into a temporary
+// file in the same directory, which replaces file at path only after write
+// succeeds, so a failed run never leaves a truncated file behind. Replaced
+// file keeps its permissions, a new one is readable like a file made by
+// os.Create. If path is Stdout, content is written to standard output.
+func WriteFile(path string, write func(w io.Writer) error) error {
+	if path == Stdout {
+		bw := bufio.NewWriter(os.Stdout)
+		if err := write(bw); err != nil {
+			return err
+		}
+		return bw.Flush()
+	}
+
+	perm := fs.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	directory, fileName := filepath.Split(path)
+	if directory == "" {
+		directory = "." // CreateTemp would use os.TempDir, which may be on another device
+	}
+	tmpFile, err := os.CreateTemp(directory, "."+fileName+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath) // fails harmlessly once file is renamed
+
+	bw := bufio.NewWriter(tmpFile)
+	if err := write(bw); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// returns paths of .asm files in directory and its subdirectories, sorted.
+func FindAsmFiles(directory string) ([]string, error) {
+	found := []string{}
+	err := filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(path), ".asm") {
+			found = append(found, path)
+		}
+		return nil
+	})
+	sort.Strings(found)
+	return found, err
+}
+
+// reads description of target machine from file at path, see assembler.ReadTarget.
+func ReadTarget(path string) (assembler.Target, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return assembler.Target{}, err
+	}
+	defer file.Close()
+	return assembler.ReadTarget(file)
+}
+
+// reads machine code of program at path in format named by format, as
+// -format flag gives it. If format is empty, it is known from extension of
+// path and .asm is assembled, so labels and source lines of program are known.
+func ReadProgram(path, format string) (assembler.Program, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return assembler.Program{}, err
+	}
+	defer file.Close()
+
+	if format == "" && strings.EqualFold(filepath.Ext(path), ".asm") {
+		return assembler.Assemble(file, path)
+	}
+
+	inputFormat, ok := formats.Lookup(format)
+	if format == "" {
+		inputFormat, ok = formats.ByExtension(filepath.Ext(path))
+	}
+	if !ok {
+		return assembler.Program{}, fmt.Errorf("unknown format, use -format with one of %v", strings.Join(formats.Names(), ", "))
+	}
+	words, err := inputFormat.Read(file)
+	return assembler.Program{Name: path, Words: words}, err
+}
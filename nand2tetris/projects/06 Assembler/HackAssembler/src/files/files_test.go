@@ -0,0 +1,115 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Prog.hack")
+
+	err := WriteFile(path, func(w io.Writer) error {
+		_, err := fmt.Fprint(w, "0000000000000001\n")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// failed write keeps previous file as it was
+	err = WriteFile(path, func(w io.Writer) error {
+		fmt.Fprint(w, "00000")
+		return errors.New("disk full")
+	})
+	if err == nil {
+		t.Fatalf("expected error of write to be returned")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "0000000000000001\n" {
+		t.Fatalf("expected previous content, but got=%q", content)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected temporary files to be removed, but got=%v", entries)
+	}
+}
+
+func TestWriteFileKeepsPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Prog.asm")
+	if err := os.WriteFile(path, []byte("@0\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err := WriteFile(path, func(w io.Writer) error {
+		_, err := fmt.Fprint(w, "@1\n")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected=%v, but got=%v", fs.FileMode(0o600), info.Mode().Perm())
+	}
+}
+
+func TestReadProgram(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	asm := write("Prog.asm", "(LOOP)\n@LOOP\n0;JMP\n")
+	hack := write("Prog.hack", "0000000000000000\n1110101010000111\n")
+	hex := write("Prog.txt", "0000\nEA87\n")
+
+	tests := []struct {
+		path, format string
+		labels       bool // assembled, so labels are known
+	}{
+		{asm, "", true},
+		{hack, "", false},
+		{hex, "hex", false},
+	}
+
+	for i, tt := range tests {
+		program, err := ReadProgram(tt.path, tt.format)
+		if err != nil {
+			t.Fatalf("tests[%d]: %v", i, err)
+		}
+		if len(program.Words) != 2 || program.Words[0] != 0 || program.Words[1] != 0xEA87 {
+			t.Fatalf("tests[%d]: expected=[0 60039], but got=%v", i, program.Words)
+		}
+		if program.Name != tt.path {
+			t.Fatalf("tests[%d]: expected=%v, but got=%v", i, tt.path, program.Name)
+		}
+		if labels := program.Symbols.Contains("LOOP"); labels != tt.labels {
+			t.Fatalf("tests[%d]: expected labels=%v, but got=%v", i, tt.labels, labels)
+		}
+	}
+
+	_, err := ReadProgram(write("Prog.xyz", ""), "")
+	if err == nil || !strings.Contains(err.Error(), "unknown format") {
+		t.Fatalf("expected=unknown format, but got=%v", err)
+	}
+}
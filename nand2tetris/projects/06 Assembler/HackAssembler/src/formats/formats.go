@@ -0,0 +1,162 @@
+package formats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format writes and reads machine code in some file format, words[i] is
+// the instruction stored at ROM address i.
+type Format interface {
+	// file extension used for this format, including the leading dot
+	Extension() string
+	Write(w io.Writer, words []uint16) error
+	Read(r io.Reader) ([]uint16, error)
+}
+
+// key: format name as given to -format flag
+var formats = map[string]Format{}
+
+// makes f available under name, registering a name again replaces older format.
+func Register(name string, f Format) {
+	formats[name] = f
+}
+
+func Lookup(name string) (Format, bool) {
+	f, ok := formats[name]
+	return f, ok
+}
+
+// returns names of all registered formats in sorted order.
+func Names() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// returns format whose extension is ext, if several formats share the
+// extension, the one with smallest name is returned, eg: bin for .bin
+func ByExtension(ext string) (Format, bool) {
+	for _, name := range Names() {
+		if formats[name].Extension() == ext {
+			return formats[name], true
+		}
+	}
+	return nil, false
+}
+
+func init() {
+	Register("hack", Hack{})
+	Register("hex", Hex{})
+	Register("bin", Bin{LittleEndian: false})
+	Register("binle", Bin{LittleEndian: true})
+	Register("ihex", IntelHex{})
+	Register("mif", MIF{})
+	Register("coe", COE{})
+}
+
+// Hack is the .hack format of the course, each instruction as a line of 16 '0'/'1' characters.
+type Hack struct{}
+
+func (Hack) Extension() string {
+	return ".hack"
+}
+
+func (Hack) Write(w io.Writer, words []uint16) error {
+	bw := bufio.NewWriter(w)
+	for _, word := range words {
+		fmt.Fprintf(bw, "%016b\n", word)
+	}
+	return bw.Flush()
+}
+
+func (Hack) Read(r io.Reader) ([]uint16, error) {
+	return readWordLines(r, 2, 16)
+}
+
+// Hex writes each instruction as a line of 4 hex digits, as read by verilog's $readmemh.
+type Hex struct{}
+
+func (Hex) Extension() string {
+	return ".hex"
+}
+
+func (Hex) Write(w io.Writer, words []uint16) error {
+	bw := bufio.NewWriter(w)
+	for _, word := range words {
+		fmt.Fprintf(bw, "%04X\n", word)
+	}
+	return bw.Flush()
+}
+
+func (Hex) Read(r io.Reader) ([]uint16, error) {
+	return readWordLines(r, 16, 4)
+}
+
+// Bin writes raw instructions, 2 bytes each, without any header.
+type Bin struct {
+	LittleEndian bool
+}
+
+func (Bin) Extension() string {
+	return ".bin"
+}
+
+func (b Bin) Write(w io.Writer, words []uint16) error {
+	raw := make([]byte, 0, 2*len(words))
+	for _, word := range words {
+		if b.LittleEndian {
+			raw = append(raw, byte(word), byte(word>>8))
+		} else {
+			raw = append(raw, byte(word>>8), byte(word))
+		}
+	}
+	_, err := w.Write(raw)
+	return err
+}
+
+func (b Bin) Read(r io.Reader) ([]uint16, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw)%2 != 0 {
+		return nil, fmt.Errorf("length of binary is %d bytes, expected even length", len(raw))
+	}
+
+	words := make([]uint16, 0, len(raw)/2)
+	for i := 0; i < len(raw); i += 2 {
+		if b.LittleEndian {
+			words = append(words, uint16(raw[i])|uint16(raw[i+1])<<8)
+		} else {
+			words = append(words, uint16(raw[i])<<8|uint16(raw[i+1]))
+		}
+	}
+	return words, nil
+}
+
+// reads one word per line, each written with exactly digits digits in given base,
+// blank lines are skipped.
+func readWordLines(r io.Reader, base int, digits int) ([]uint16, error) {
+	words := []uint16{}
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		word, err := strconv.ParseUint(line, base, 16)
+		if err != nil || len(line) != digits {
+			return nil, fmt.Errorf("line %d: %q is not a word of %d base %d digits", lineNumber, line, digits, base)
+		}
+		words = append(words, uint16(word))
+	}
+	return words, scanner.Err()
+}
@@ -0,0 +1,169 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFormats(t *testing.T) {
+	words := []uint16{0x0002, 0xEC10, 0x7FFF}
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"hack", "0000000000000010\n1110110000010000\n0111111111111111\n"},
+		{"hex", "0002\nEC10\n7FFF\n"},
+		{"bin", "\x00\x02\xEC\x10\x7F\xFF"},
+		{"binle", "\x02\x00\x10\xEC\xFF\x7F"},
+		{"ihex", ":060000000002EC107FFF7E\n:00000001FF\n"},
+	}
+
+	for i, tt := range tests {
+		f, ok := Lookup(tt.name)
+		if !ok {
+			t.Fatalf("tests[%d]: format %v is not registered", i, tt.name)
+		}
+
+		var out bytes.Buffer
+		if err := f.Write(&out, words); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != tt.expected {
+			t.Fatalf("tests[%d]: %v expected=%q, but got=%q", i, tt.name, tt.expected, out.String())
+		}
+	}
+}
+
+func TestIntelHexChecksum(t *testing.T) {
+	words := make([]uint16, 100)
+	for i := range words {
+		words[i] = uint16(i * 977)
+	}
+
+	var out bytes.Buffer
+	if err := (IntelHex{}).Write(&out, words); err != nil {
+		t.Fatal(err)
+	}
+
+	records := strings.Fields(out.String())
+	if len(records) != 200/ihexRecordLength+2 {
+		t.Fatalf("expected %d records, but got=%d", 200/ihexRecordLength+2, len(records))
+	}
+	for i, record := range records {
+		raw, err := hex.DecodeString(strings.TrimPrefix(record, ":"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sum byte
+		for _, b := range raw {
+			sum += b
+		}
+		if sum != 0 {
+			t.Fatalf("records[%d]: %v bytes do not sum to 0", i, record)
+		}
+	}
+}
+
+func TestMemoryInitializationFiles(t *testing.T) {
+	words := []uint16{0x0002, 0xEC10}
+
+	var mif bytes.Buffer
+	if err := (MIF{}).Write(&mif, words); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"DEPTH = 32768;", "1 : 1110110000010000;", "[2..32767] : 0000000000000000;", "END;"} {
+		if !strings.Contains(mif.String(), line+"\n") {
+			t.Fatalf("expected mif to contain %q", line)
+		}
+	}
+
+	var coe bytes.Buffer
+	if err := (COE{}).Write(&coe, words); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(coe.String()), "\n")
+	if len(lines) != romSize+3 || lines[4] != "EC10," || lines[len(lines)-1] != "0000;" {
+		t.Fatalf("unexpected coe layout, %d lines, %q ... %q", len(lines), lines[4], lines[len(lines)-1])
+	}
+}
+
+func TestReadBack(t *testing.T) {
+	words := make([]uint16, 37)
+	for i := range words {
+		words[i] = uint16(i*4099 + 1)
+	}
+
+	for _, name := range Names() {
+		f, _ := Lookup(name)
+		var out bytes.Buffer
+		if err := f.Write(&out, words); err != nil {
+			t.Fatal(err)
+		}
+		got, err := f.Read(&out)
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		if len(got) != len(words) {
+			t.Fatalf("%v: expected %d words, but got=%d", name, len(words), len(got))
+		}
+		for i := range words {
+			if got[i] != words[i] {
+				t.Fatalf("%v: words[%d] expected=%04X, but got=%04X", name, i, words[i], got[i])
+			}
+		}
+	}
+}
+
+func TestReadBackTrailingZeros(t *testing.T) {
+	tests := [][]uint16{
+		{},
+		{0},
+		{0xEC10, 0, 0},
+		{0, 0xEC10, 0},
+	}
+
+	for i, words := range tests {
+		for _, f := range []Format{MIF{}, COE{}} {
+			var out bytes.Buffer
+			if err := f.Write(&out, words); err != nil {
+				t.Fatal(err)
+			}
+			got, err := f.Read(&out)
+			if err != nil {
+				t.Fatalf("tests[%d]: %v: %v", i, f.Extension(), err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(words) {
+				t.Fatalf("tests[%d]: %v: expected=%v, but got=%v", i, f.Extension(), words, got)
+			}
+		}
+	}
+}
+
+func TestReadMIF(t *testing.T) {
+	input := `-- hand written
+WIDTH=16; DEPTH=8;
+ADDRESS_RADIX=HEX; DATA_RADIX=HEX;
+CONTENT BEGIN
+	0 : 0002;
+	[1..2] : EC10; % two words %
+	4 : 7FFF;
+END;
+`
+	expected := []uint16{0x0002, 0xEC10, 0xEC10, 0, 0x7FFF}
+
+	got, err := (MIF{}).Read(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected=%v, but got=%v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected=%v, but got=%v", expected, got)
+		}
+	}
+}
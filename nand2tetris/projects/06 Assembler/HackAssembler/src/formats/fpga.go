@@ -0,0 +1,242 @@
+package formats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// memory initialization files for FPGA tools, both describe the whole ROM
+// of Hack computer, so memory beyond program is filled with zeros. Padding
+// is written so that reading the file back gives exactly the program: MIF
+// pads with a range of zeros after the last addressed word and COE notes
+// length of program in a comment.
+
+const romSize = 32768
+
+// MIF is Altera/Intel Memory Initialization File.
+type MIF struct{}
+
+func (MIF) Extension() string {
+	return ".mif"
+}
+
+func (MIF) Write(w io.Writer, words []uint16) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "DEPTH = %d;\n", romSize)
+	fmt.Fprintf(bw, "WIDTH = 16;\n")
+	fmt.Fprintf(bw, "ADDRESS_RADIX = DEC;\n")
+	fmt.Fprintf(bw, "DATA_RADIX = BIN;\n")
+	fmt.Fprintf(bw, "CONTENT\nBEGIN\n")
+	for address, word := range words {
+		fmt.Fprintf(bw, "%d : %016b;\n", address, word)
+	}
+	if len(words) < romSize {
+		fmt.Fprintf(bw, "[%d..%d] : %016b;\n", len(words), romSize-1, 0)
+	}
+	fmt.Fprintf(bw, "END;\n")
+	return bw.Flush()
+}
+
+// reads MIF content section, header may give radix of addresses and data in
+// BIN, HEX, OCT, DEC or UNS, entries are "address : value;" or "[first..last] : value;"
+func (MIF) Read(r io.Reader) ([]uint16, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text := stripMIFComments(string(raw))
+
+	begin := strings.Index(strings.ToUpper(text), "BEGIN")
+	if begin == -1 {
+		return nil, fmt.Errorf("CONTENT BEGIN is missing")
+	}
+	addressRadix, dataRadix := 16, 16 // when header does not specify radix, HEX is assumed
+	for _, setting := range strings.Split(text[:begin], ";") {
+		key, value, ok := strings.Cut(setting, "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToUpper(strings.TrimSpace(key)), strings.ToUpper(strings.TrimSpace(value))
+		switch key {
+		case "ADDRESS_RADIX", "DATA_RADIX":
+			radix, ok := mifRadix[value]
+			if !ok {
+				return nil, fmt.Errorf("unsupported %v %v", key, value)
+			}
+			if key == "ADDRESS_RADIX" {
+				addressRadix = radix
+			} else {
+				dataRadix = radix
+			}
+		}
+	}
+
+	// program ends at the last word given by its own address, ranges of
+	// zeros only pad ROM after it
+	words, length := []uint16{}, 0
+	content := text[begin+len("BEGIN"):]
+	for _, entry := range strings.Split(content, ";") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 || strings.EqualFold(entry, "END") {
+			continue
+		}
+		addressPart, valuePart, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed entry %q, expected address : value", entry)
+		}
+		value, err := strconv.ParseUint(strings.TrimSpace(valuePart), dataRadix, 16)
+		if err != nil {
+			return nil, fmt.Errorf("malformed value in entry %q", entry)
+		}
+
+		first, last, err := parseMIFAddresses(strings.TrimSpace(addressPart), addressRadix)
+		if err != nil {
+			return nil, fmt.Errorf("malformed address in entry %q", entry)
+		}
+		for len(words) <= last {
+			words = append(words, 0)
+		}
+		for address := first; address <= last; address++ {
+			words[address] = uint16(value)
+		}
+		if (first == last || value != 0) && last >= length {
+			length = last + 1
+		}
+	}
+	return words[:length], nil
+}
+
+var mifRadix = map[string]int{"BIN": 2, "OCT": 8, "DEC": 10, "UNS": 10, "HEX": 16}
+
+// address or [first..last]
+func parseMIFAddresses(s string, radix int) (int, int, error) {
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		firstPart, lastPart, ok := strings.Cut(s[1:len(s)-1], "..")
+		if !ok {
+			return 0, 0, fmt.Errorf("malformed range %v", s)
+		}
+		first, err := strconv.ParseUint(strings.TrimSpace(firstPart), radix, 16)
+		if err != nil {
+			return 0, 0, err
+		}
+		last, err := strconv.ParseUint(strings.TrimSpace(lastPart), radix, 16)
+		if err != nil || last < first {
+			return 0, 0, fmt.Errorf("malformed range %v", s)
+		}
+		return int(first), int(last), nil
+	}
+	address, err := strconv.ParseUint(s, radix, 16)
+	return int(address), int(address), err
+}
+
+// MIF comments start with -- and end at the line end, or are enclosed in %
+func stripMIFComments(text string) string {
+	var stripped strings.Builder
+	inComment := false
+	for _, line := range strings.Split(text, "\n") {
+		if at := strings.Index(line, "--"); at != -1 && !inComment {
+			line = line[:at]
+		}
+		for _, char := range line {
+			if char == '%' {
+				inComment = !inComment
+				continue
+			}
+			if !inComment {
+				stripped.WriteRune(char)
+			}
+		}
+		stripped.WriteRune('\n')
+	}
+	return stripped.String()
+}
+
+// COE is Xilinx coefficient file, as read by block memory generator.
+type COE struct{}
+
+func (COE) Extension() string {
+	return ".coe"
+}
+
+func (COE) Write(w io.Writer, words []uint16) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, coeLength+"\n", len(words))
+	fmt.Fprintf(bw, "memory_initialization_radix=16;\n")
+	fmt.Fprintf(bw, "memory_initialization_vector=\n")
+	for address := 0; address < romSize; address++ {
+		var word uint16
+		if address < len(words) {
+			word = words[address]
+		}
+		separator := ","
+		if address == romSize-1 {
+			separator = ";"
+		}
+		fmt.Fprintf(bw, "%04X%s\n", word, separator)
+	}
+	return bw.Flush()
+}
+
+func (COE) Read(r io.Reader) ([]uint16, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	length := -1 // files not written by COE.Write give whole vector
+	for _, line := range strings.Split(string(raw), "\n") {
+		if n, err := fmt.Sscanf(strings.TrimSpace(line), coeLength, &length); n == 1 && err == nil {
+			break
+		}
+	}
+
+	radix := 10 // default radix of coe files
+	words := []uint16{}
+	for _, statement := range strings.Split(stripCOEComments(string(raw)), ";") {
+		key, value, ok := strings.Cut(statement, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "memory_initialization_radix":
+			v, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || (v != 2 && v != 10 && v != 16) {
+				return nil, fmt.Errorf("unsupported memory_initialization_radix %v", strings.TrimSpace(value))
+			}
+			radix = v
+		case "memory_initialization_vector":
+			for _, field := range strings.FieldsFunc(value, func(r rune) bool {
+				return r == ',' || unicode.IsSpace(r)
+			}) {
+				word, err := strconv.ParseUint(field, radix, 16)
+				if err != nil {
+					return nil, fmt.Errorf("malformed value %q in memory_initialization_vector", field)
+				}
+				words = append(words, uint16(word))
+			}
+		}
+	}
+	if length >= 0 && length <= len(words) {
+		words = words[:length]
+	}
+	return words, nil
+}
+
+// comment written by COE.Write before vector, it gets number of words of program
+const coeLength = "; program is %d words, rest of vector is zero"
+
+// COE comments start with ; only at the beginning of a line, but ; also ends
+// statements, so comment lines are dropped before splitting statements.
+func stripCOEComments(text string) string {
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), ";") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,109 @@
+package formats
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// IntelHex writes instructions as Intel HEX data records, instructions are
+// stored big-endian and byte addressed, instruction at ROM address i is at
+// byte address 2*i. 32K instructions fill exactly the 64K bytes a record can
+// address, so extended address records are never needed.
+type IntelHex struct{}
+
+// data bytes per record
+const ihexRecordLength = 16
+
+const (
+	ihexData = 0x00
+	ihexEOF  = 0x01
+)
+
+func (IntelHex) Extension() string {
+	return ".ihx"
+}
+
+func (IntelHex) Write(w io.Writer, words []uint16) error {
+	raw := make([]byte, 0, 2*len(words))
+	for _, word := range words {
+		raw = append(raw, byte(word>>8), byte(word))
+	}
+
+	bw := bufio.NewWriter(w)
+	for address := 0; address < len(raw); address += ihexRecordLength {
+		end := address + ihexRecordLength
+		if end > len(raw) {
+			end = len(raw)
+		}
+		writeIHexRecord(bw, address, ihexData, raw[address:end])
+	}
+	writeIHexRecord(bw, 0, ihexEOF, nil)
+	return bw.Flush()
+}
+
+// :LLAAAATT[DD...]CC, CC is two's complement of sum of all preceding bytes
+func writeIHexRecord(w io.Writer, address int, recordType byte, data []byte) {
+	sum := byte(len(data)) + byte(address>>8) + byte(address) + recordType
+	fmt.Fprintf(w, ":%02X%04X%02X", len(data), address&0xFFFF, recordType)
+	for _, b := range data {
+		fmt.Fprintf(w, "%02X", b)
+		sum += b
+	}
+	fmt.Fprintf(w, "%02X\n", -sum)
+}
+
+func (IntelHex) Read(r io.Reader) ([]uint16, error) {
+	raw := []byte{}
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		record, err := hex.DecodeString(strings.TrimPrefix(line, ":"))
+		if err != nil || line[0] != ':' || len(record) < 5 || len(record) != int(record[0])+5 {
+			return nil, fmt.Errorf("line %d: malformed record %q", lineNumber, line)
+		}
+		var sum byte
+		for _, b := range record {
+			sum += b
+		}
+		if sum != 0 {
+			return nil, fmt.Errorf("line %d: checksum mismatch in record %q", lineNumber, line)
+		}
+
+		address := int(record[1])<<8 | int(record[2])
+		data := record[4 : len(record)-1]
+		switch recordType := record[3]; recordType {
+		case ihexData:
+			for len(raw) < address+len(data) {
+				raw = append(raw, 0)
+			}
+			copy(raw[address:], data)
+		case ihexEOF:
+			return bytesToWords(raw), nil
+		default:
+			return nil, fmt.Errorf("line %d: unsupported record type %02X", lineNumber, recordType)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("missing end of file record")
+}
+
+// big-endian bytes to words, odd last byte is the high byte of last word
+func bytesToWords(raw []byte) []uint16 {
+	words := make([]uint16, (len(raw)+1)/2)
+	for i, b := range raw {
+		if i%2 == 0 {
+			words[i/2] |= uint16(b) << 8
+		} else {
+			words[i/2] |= uint16(b)
+		}
+	}
+	return words
+}
@@ -0,0 +1,344 @@
+// Package jit runs Hack machine code faster than the emulator interprets it,
+// the program is translated into Go closures, one per instruction, grouped
+// in basic blocks: straight runs of instructions which end with a jump or
+// before an instruction a jump goes to. Jump targets loaded by @label right
+// before the jump are known when translating, so blocks are linked to the
+// blocks they jump to. A computed jump, eg: return of a VM function to an
+// address read from memory, may land inside a block, from there instructions
+// are interpreted until a block starts, and an address such jumps land on
+// often gets a block of its own.
+package jit
+
+import (
+	"github.com/ishwar00/HackAssembler/emulator"
+)
+
+// number of times interpreter enters an address before a block is
+// translated for it
+const HOT_ENTRIES = 16
+
+const RAM_MASK = emulator.RAM_SIZE - 1
+
+// an instruction which does not jump
+type op func(c *emulator.Computer)
+
+type block struct {
+	start, end int // ROM addresses of instructions [start, end)
+	ops        []op
+
+	// last instruction jumps, exit executes it and returns true if jump is
+	// taken, to targetAddress if it is known, -1 otherwise. target and next
+	// are blocks at targetAddress and end, nil if no block starts there.
+	jump          bool
+	exit          func(c *emulator.Computer) bool
+	targetAddress int
+	target        *block
+	next          *block
+
+	// block is the final loop of program, see emulator.Computer.Halted
+	halts bool
+}
+
+// Program is machine code translated into blocks, it runs on a computer
+// whose ROM holds the same machine code.
+type Program struct {
+	words   []uint16
+	blocks  [emulator.ROM_SIZE]*block // key: ROM address where block starts
+	leaders [emulator.ROM_SIZE]bool   // addresses where blocks start
+	hits    [emulator.ROM_SIZE]uint16 // jumps interpreted to address
+}
+
+// Compile translates machine code into blocks.
+func Compile(words []uint16) *Program {
+	p := &Program{words: words}
+	if len(words) == 0 {
+		return p
+	}
+
+	// first instructions of blocks
+	p.leaders[0] = true
+	a, aKnown := 0, false // value of A, if it is known from an A instruction
+	for address, word := range words {
+		if word&0x8000 == 0 {
+			a, aKnown = int(word), true
+			continue
+		}
+		if word&0x0007 != 0 {
+			if address+1 < len(words) {
+				p.leaders[address+1] = true
+			}
+			if aKnown && a < len(words) {
+				p.leaders[a] = true
+			}
+		}
+		if word&0x0020 != 0 { // A is written
+			aKnown = false
+		}
+	}
+
+	for address := range words {
+		if p.leaders[address] {
+			p.compileBlock(address)
+		}
+	}
+	for _, b := range p.blocks {
+		if b != nil {
+			p.link(b)
+		}
+	}
+	return p
+}
+
+// translates instructions from start up to the first jump or leader.
+func (p *Program) compileBlock(start int) *block {
+	b := &block{start: start, targetAddress: -1}
+	a, aKnown := 0, false
+	address := start
+	for ; address < len(p.words); address++ {
+		if address != start && p.leaders[address] {
+			break
+		}
+		word := p.words[address]
+		if word&0x8000 == 0 {
+			b.ops = append(b.ops, loadA(word))
+			a, aKnown = int(word), true
+			continue
+		}
+		if word&0x0007 != 0 {
+			b.jump = true
+			b.exit = jumpOp(word)
+			if aKnown && a < len(p.words) {
+				b.targetAddress = a
+			}
+			address++
+			break
+		}
+		b.ops = append(b.ops, computeOp(word))
+		if word&0x0020 != 0 {
+			aKnown = false
+		}
+	}
+	b.end = address
+
+	b.halts = start+1 < len(p.words) && int(p.words[start]) == start &&
+		p.words[start+1]&0x8000 != 0 && p.words[start+1]&0x0038 == 0 && p.words[start+1]&0x0007 == 0x0007
+	p.blocks[start], p.leaders[start] = b, true
+	return b
+}
+
+// resolves target and next block of b, they are nil if no block starts there.
+func (p *Program) link(b *block) {
+	if b.targetAddress >= 0 {
+		b.target = p.blocks[b.targetAddress]
+	}
+	if b.end < len(p.words) {
+		b.next = p.blocks[b.end]
+	}
+}
+
+// Run executes instructions like emulator.Computer.Run, leaving computer in
+// the same state after the same number of instructions.
+func (p *Program) Run(c *emulator.Computer, maxCycles uint64) (cycles uint64, halted bool) {
+	var b *block
+	jumped := true // PC is not reached from the instruction before it
+	for maxCycles == 0 || cycles < maxCycles {
+		if b == nil || int(c.PC) != b.start {
+			b = p.blocks[c.PC&(emulator.ROM_SIZE-1)]
+			if b == nil && jumped {
+				b = p.enter(c.PC)
+			}
+		}
+		length := uint64(0)
+		if b != nil {
+			length = uint64(b.end - b.start)
+		}
+
+		// interpret outside of blocks, and when a block does not fit in
+		// remaining cycles
+		if b == nil || maxCycles != 0 && cycles+length > maxCycles {
+			if c.Halted() {
+				return cycles, true
+			}
+			pc := c.PC
+			c.Step()
+			cycles++
+			jumped, b = c.PC != pc+1, nil
+			continue
+		}
+		if b.halts {
+			return cycles, true
+		}
+
+		for _, op := range b.ops {
+			op(c)
+		}
+		c.Cycles += length
+		cycles += length
+		if jumped = b.jump && b.exit(c); jumped {
+			b = b.target
+		} else {
+			c.PC = uint16(b.end)
+			b = b.next
+		}
+	}
+	return cycles, c.Halted()
+}
+
+// counts jumps to address, which has no block, and translates a block for it
+// once it is entered often, returns the block or nil.
+func (p *Program) enter(pc uint16) *block {
+	address := int(pc)
+	if address >= len(p.words) {
+		return nil
+	}
+	p.hits[address]++
+	if p.hits[address] < HOT_ENTRIES {
+		return nil
+	}
+	b := p.compileBlock(address)
+	p.link(b)
+	return b
+}
+
+// Blocks returns number of blocks translated so far.
+func (p *Program) Blocks() int {
+	n := 0
+	for _, b := range p.blocks {
+		if b != nil {
+			n++
+		}
+	}
+	return n
+}
+
+func loadA(word uint16) op {
+	return func(c *emulator.Computer) { c.A = word }
+}
+
+// C instruction without jump, M is written at address A held before it.
+func computeOp(instr uint16) op {
+	comp := compOf(instr)
+	switch (instr >> 3) & 7 {
+	case 0:
+		return func(c *emulator.Computer) {}
+	case 1: // M
+		return func(c *emulator.Computer) { c.RAM[c.A&RAM_MASK] = comp(c) }
+	case 2: // D
+		return func(c *emulator.Computer) { c.D = comp(c) }
+	case 3: // MD
+		return func(c *emulator.Computer) {
+			v := comp(c)
+			c.RAM[c.A&RAM_MASK], c.D = v, v
+		}
+	case 4: // A
+		return func(c *emulator.Computer) { c.A = comp(c) }
+	case 5: // AM
+		return func(c *emulator.Computer) {
+			v := comp(c)
+			c.RAM[c.A&RAM_MASK], c.A = v, v
+		}
+	case 6: // AD
+		return func(c *emulator.Computer) {
+			v := comp(c)
+			c.A, c.D = v, v
+		}
+	default: // AMD
+		return func(c *emulator.Computer) {
+			v := comp(c)
+			c.RAM[c.A&RAM_MASK], c.A, c.D = v, v, v
+		}
+	}
+}
+
+// C instruction with jump, it sets PC to A held before it if jump is taken.
+func jumpOp(instr uint16) func(c *emulator.Computer) bool {
+	if instr&0x0038 == 0 && instr&0x0007 == 0x0007 { // 0;JMP
+		return func(c *emulator.Computer) bool {
+			c.PC = c.A
+			return true
+		}
+	}
+	comp, dest := compOf(instr), (instr>>3)&7
+	return func(c *emulator.Computer) bool {
+		a := c.A
+		out := comp(c)
+		if dest&1 != 0 { // M
+			c.RAM[a&RAM_MASK] = out
+		}
+		if dest&4 != 0 { // A
+			c.A = out
+		}
+		if dest&2 != 0 { // D
+			c.D = out
+		}
+		if emulator.Jumps(out, instr) {
+			c.PC = a
+			return true
+		}
+		return false
+	}
+}
+
+// comp of C instruction, the common ones do not go through the ALU.
+func compOf(instr uint16) func(c *emulator.Computer) uint16 {
+	m := instr&0x1000 != 0
+	control := (instr >> 6) & 0x3f
+	switch control {
+	case 0x2a: // 0
+		return func(c *emulator.Computer) uint16 { return 0 }
+	case 0x3f: // 1
+		return func(c *emulator.Computer) uint16 { return 1 }
+	case 0x3a: // -1
+		return func(c *emulator.Computer) uint16 { return 0xffff }
+	case 0x0c: // D
+		return func(c *emulator.Computer) uint16 { return c.D }
+	case 0x1f: // D+1
+		return func(c *emulator.Computer) uint16 { return c.D + 1 }
+	case 0x0e: // D-1
+		return func(c *emulator.Computer) uint16 { return c.D - 1 }
+	case 0x0f: // -D
+		return func(c *emulator.Computer) uint16 { return -c.D }
+	case 0x0d: // !D
+		return func(c *emulator.Computer) uint16 { return ^c.D }
+	}
+	if m {
+		switch control {
+		case 0x30:
+			return func(c *emulator.Computer) uint16 { return c.RAM[c.A&RAM_MASK] }
+		case 0x37:
+			return func(c *emulator.Computer) uint16 { return c.RAM[c.A&RAM_MASK] + 1 }
+		case 0x32:
+			return func(c *emulator.Computer) uint16 { return c.RAM[c.A&RAM_MASK] - 1 }
+		case 0x02:
+			return func(c *emulator.Computer) uint16 { return c.D + c.RAM[c.A&RAM_MASK] }
+		case 0x13:
+			return func(c *emulator.Computer) uint16 { return c.D - c.RAM[c.A&RAM_MASK] }
+		case 0x07:
+			return func(c *emulator.Computer) uint16 { return c.RAM[c.A&RAM_MASK] - c.D }
+		case 0x00:
+			return func(c *emulator.Computer) uint16 { return c.D & c.RAM[c.A&RAM_MASK] }
+		case 0x15:
+			return func(c *emulator.Computer) uint16 { return c.D | c.RAM[c.A&RAM_MASK] }
+		}
+		return func(c *emulator.Computer) uint16 { return emulator.ALU(c.D, c.RAM[c.A&RAM_MASK], control) }
+	}
+	switch control {
+	case 0x30:
+		return func(c *emulator.Computer) uint16 { return c.A }
+	case 0x37:
+		return func(c *emulator.Computer) uint16 { return c.A + 1 }
+	case 0x32:
+		return func(c *emulator.Computer) uint16 { return c.A - 1 }
+	case 0x02:
+		return func(c *emulator.Computer) uint16 { return c.D + c.A }
+	case 0x13:
+		return func(c *emulator.Computer) uint16 { return c.D - c.A }
+	case 0x07:
+		return func(c *emulator.Computer) uint16 { return c.A - c.D }
+	case 0x00:
+		return func(c *emulator.Computer) uint16 { return c.D & c.A }
+	case 0x15:
+		return func(c *emulator.Computer) uint16 { return c.D | c.A }
+	}
+	return func(c *emulator.Computer) uint16 { return emulator.ALU(c.D, c.A, control) }
+}
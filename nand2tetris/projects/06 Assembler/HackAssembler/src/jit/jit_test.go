@@ -0,0 +1,135 @@
+package jit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ishwar00/HackAssembler/assembler"
+	"github.com/ishwar00/HackAssembler/emulator"
+	"github.com/ishwar00/HackAssembler/files"
+)
+
+const PONG = "../../../pong/Pong.asm"
+
+func assemble(t testing.TB, asmPath string) []uint16 {
+	t.Helper()
+	program, err := files.ReadProgram(asmPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return program.Words
+}
+
+func load(t testing.TB, words []uint16, set map[int]uint16) *emulator.Computer {
+	t.Helper()
+	computer := new(emulator.Computer)
+	if err := computer.Load(words); err != nil {
+		t.Fatal(err)
+	}
+	for address, value := range set {
+		computer.RAM[address] = value
+	}
+	return computer
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		asmPath string
+		set     map[int]uint16
+		cycles  uint64
+		chunk   uint64 // cycles per call of Run
+	}{
+		{"../../../../04 Machine Language/mult/Mult.asm", map[int]uint16{0: 7, 1: 9}, 1000, 0},
+		{"../../../../04 Machine Language/mult/Mult.asm", map[int]uint16{0: 7, 1: 9}, 1000, 7},
+		{"../../../../04 Machine Language/fill/Fill.asm", map[int]uint16{emulator.KBD: 1}, 300000, 0},
+		{"../../../../08 Virtual Machine 2 (control)/FunctionCalls/FibonacciElement/FibonacciElement.asm", nil, 10000, 0},
+		{"../../../../08 Virtual Machine 2 (control)/FunctionCalls/FibonacciElement/FibonacciElement.asm", nil, 10000, 13},
+		{PONG, nil, 3000000, 0},
+		{PONG, nil, 3000000, 777},
+	}
+
+	for i, tt := range tests {
+		words := assemble(t, tt.asmPath)
+		expected := load(t, words, tt.set)
+		expectedCycles, expectedHalted := expected.Run(tt.cycles)
+
+		got := load(t, words, tt.set)
+		p := Compile(words)
+		var gotCycles uint64
+		gotHalted := false
+		for gotCycles < tt.cycles && !gotHalted {
+			chunk := tt.cycles - gotCycles
+			if tt.chunk != 0 && tt.chunk < chunk {
+				chunk = tt.chunk
+			}
+			cycles, halted := p.Run(got, chunk)
+			gotCycles, gotHalted = gotCycles+cycles, halted
+		}
+
+		if gotCycles != expectedCycles || gotHalted != expectedHalted {
+			t.Fatalf("tests[%d]: %v: expected=%d cycles halted=%v, but got=%d cycles halted=%v",
+				i, tt.asmPath, expectedCycles, expectedHalted, gotCycles, gotHalted)
+		}
+		if *got != *expected {
+			t.Fatalf("tests[%d]: %v: expected computer A=%d D=%d PC=%d cycles=%d, but got A=%d D=%d PC=%d cycles=%d, or RAM differs",
+				i, tt.asmPath, expected.A, expected.D, expected.PC, expected.Cycles, got.A, got.D, got.PC, got.Cycles)
+		}
+	}
+}
+
+func TestComputedJumps(t *testing.T) {
+	// jump to address in D lands in the middle of a block, again and again
+	source := strings.Join([]string{
+		"@100", "D=A", "@R0", "M=D", // R0 = 100
+		"(LOOP)",
+		"@R0", "M=M-1", "D=M", "@END", "D;JEQ",
+		"@MIDDLE", "D=A", "@1", "D=D+A", "A=D", "0;JMP", // jumps to MIDDLE+1
+		"(MIDDLE)",
+		"D=0", "@R1", "M=M+1", "@LOOP", "0;JMP",
+		"(END)", "@END", "0;JMP",
+	}, "\n")
+	program, err := assembler.Assemble(strings.NewReader(source), "test.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Compile(program.Words)
+	before := p.Blocks()
+	computer := load(t, program.Words, nil)
+	if _, halted := p.Run(computer, 100000); !halted {
+		t.Fatalf("expected program to halt")
+	}
+	if computer.RAM[0] != 0 || computer.RAM[1] != 99 {
+		t.Fatalf("expected=R0 0 R1 99, but got=R0 %d R1 %d", computer.RAM[0], computer.RAM[1])
+	}
+	if p.Blocks() != before+1 {
+		t.Fatalf("expected=a block for target of computed jump, but got=%d blocks from %d", p.Blocks(), before)
+	}
+}
+
+// go test -bench . ./jit
+func benchmark(b *testing.B, run func(c *emulator.Computer, maxCycles uint64) uint64) {
+	words := assemble(b, PONG)
+	computer := load(b, words, nil)
+	b.ResetTimer()
+	start, cycles := time.Now(), uint64(0)
+	for i := 0; i < b.N; i++ {
+		cycles += run(computer, 1000000)
+	}
+	b.ReportMetric(float64(cycles)/time.Since(start).Seconds(), "instr/s")
+}
+
+func BenchmarkInterpreter(b *testing.B) {
+	benchmark(b, func(c *emulator.Computer, maxCycles uint64) uint64 {
+		cycles, _ := c.Run(maxCycles)
+		return cycles
+	})
+}
+
+func BenchmarkJIT(b *testing.B) {
+	p := Compile(assemble(b, PONG))
+	benchmark(b, func(c *emulator.Computer, maxCycles uint64) uint64 {
+		cycles, _ := p.Run(c, maxCycles)
+		return cycles
+	})
+}
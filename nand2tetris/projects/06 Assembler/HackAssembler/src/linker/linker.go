@@ -0,0 +1,167 @@
+// Package linker combines separately assembled modules, see
+// assembler.AssembleObject, into one Hack program.
+package linker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ishwar00/HackAssembler/assembler"
+	"github.com/ishwar00/HackAssembler/object"
+)
+
+// largest value an A instruction can load
+const maxAValue = 1<<15 - 1
+
+// Errors holds every problem found while linking, one per entry.
+type Errors []string
+
+func (e Errors) Error() string {
+	return strings.Join(e, "\n")
+}
+
+// Link places objects one after another in ROM, in the given order, and
+// resolves their relocations. A module uses a label of another module only
+// if it imports it, other symbols are variables of the module, every module
+// has its own. A variable named like a label exported by another module is
+// an error, as the module most likely misses an import. Variables are
+// allocated in order of first use, module by module.
+//
+// In Program.Symbols a variable which more than one module has is named
+// after its module, eg: Main.asm:i. Program.Instrs and Program.Source are
+// not filled, objects do not keep the source, sites of symbols name the
+// module only. If linking fails, the error is Errors holding all problems found.
+func Link(objects []object.Object, target assembler.Target) (assembler.Program, error) {
+	var st assembler.SymbolTable
+	st.InitializeWith(target)
+	errs := Errors{}
+
+	bases := make([]int, len(objects))
+	exporter := map[string]string{} // key: label, value: name of module exporting it
+	size := 0
+	for i, obj := range objects {
+		bases[i] = size
+		size += len(obj.Code)
+
+		for _, label := range sortedExports(obj) {
+			if module, ok := exporter[label]; ok {
+				errs = append(errs, fmt.Sprintf("%v: label %v is already exported by %v", obj.Name, label, module))
+				continue
+			}
+			if st.Contains(label) {
+				errs = append(errs, fmt.Sprintf("%v: exported label %v is a predefined symbol", obj.Name, label))
+				continue
+			}
+			exporter[label] = obj.Name
+			st.AddEntry(label, bases[i]+obj.Exports[label], assembler.LABEL_SYMBOL)
+			st.SetDefinedAt(label, assembler.SymbolSite{File: obj.Name})
+		}
+	}
+	if size > target.ROMSize {
+		errs = append(errs, fmt.Sprintf("program has %d instructions, but ROM holds %d", size, target.ROMSize))
+	}
+
+	imports := make([]map[string]bool, len(objects))
+	modules := map[string]int{} // key: variable, value: number of modules having it
+	for i, obj := range objects {
+		imports[i] = map[string]bool{}
+		for _, label := range obj.Imports {
+			imports[i][label] = true
+			if _, ok := exporter[label]; !ok {
+				errs = append(errs, fmt.Sprintf("%v: imported label %v is not exported by any module", obj.Name, label))
+			}
+		}
+		for _, variable := range variables(obj, imports[i]) {
+			modules[variable]++
+			if module, ok := exporter[variable]; ok {
+				errs = append(errs, fmt.Sprintf("%v: variable %v is named like label exported by %v, import the label to use it",
+					obj.Name, variable, module))
+			}
+		}
+	}
+
+	program := assembler.Program{Words: make([]uint16, 0, size)}
+	for i, obj := range objects {
+		site := assembler.SymbolSite{File: obj.Name}
+		for offset, word := range obj.Code {
+			if len(word.Relocs) == 0 {
+				program.Words = append(program.Words, uint16(word.Value))
+				continue
+			}
+
+			value := word.Value
+			for _, reloc := range word.Relocs {
+				address := bases[i]
+				switch {
+				case imports[i][reloc.Symbol]:
+					st.MarkUse(reloc.Symbol, site)
+					address = st.GetAddress(reloc.Symbol)
+				case reloc.Symbol != "":
+					name := reloc.Symbol
+					if modules[name] > 1 {
+						name = obj.Name + ":" + name
+					}
+					address = resolve(&st, name, site, &errs)
+				}
+				value += reloc.Sign * address
+			}
+			if value < 0 || value > maxAValue {
+				errs = append(errs, fmt.Sprintf("%v: instruction %d loads %d, which is not in 0 to %d",
+					obj.Name, offset, value, maxAValue))
+			}
+			program.Words = append(program.Words, uint16(value))
+		}
+	}
+
+	if len(errs) > 0 {
+		return assembler.Program{}, errs
+	}
+	if len(objects) > 0 {
+		program.Name = objects[0].Name
+	}
+	program.Symbols = st
+	return program, nil
+}
+
+// symbols of obj which are not imported, in order of first use, they are
+// variables of the module.
+func variables(obj object.Object, imported map[string]bool) []string {
+	symbols, seen := []string{}, map[string]bool{}
+	for _, word := range obj.Code {
+		for _, reloc := range word.Relocs {
+			if reloc.Symbol != "" && !imported[reloc.Symbol] && !seen[reloc.Symbol] {
+				symbols = append(symbols, reloc.Symbol)
+				seen[reloc.Symbol] = true
+			}
+		}
+	}
+	return symbols
+}
+
+// returns address of symbol, symbol which is not known yet is allocated
+// as a variable.
+func resolve(st *assembler.SymbolTable, symbol string, site assembler.SymbolSite, errs *Errors) int {
+	if !st.Contains(symbol) {
+		if err := st.AddVariable(symbol, site); err != nil {
+			*errs = append(*errs, fmt.Sprintf("%v: %v", site.File, err))
+		}
+	}
+	st.MarkUse(symbol, site)
+	return st.GetAddress(symbol)
+}
+
+// exported labels of obj ordered by offset, so errors come in order of source.
+func sortedExports(obj object.Object) []string {
+	labels := make([]string, 0, len(obj.Exports))
+	for label := range obj.Exports {
+		labels = append(labels, label)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if obj.Exports[labels[i]] == obj.Exports[labels[j]] {
+			return labels[i] < labels[j]
+		}
+		return obj.Exports[labels[i]] < obj.Exports[labels[j]]
+	})
+	return labels
+}
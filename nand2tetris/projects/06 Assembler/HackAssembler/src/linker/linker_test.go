@@ -0,0 +1,213 @@
+package linker
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ishwar00/HackAssembler/assembler"
+	"github.com/ishwar00/HackAssembler/object"
+)
+
+func assembleObjects(t *testing.T, sources ...string) []object.Object {
+	t.Helper()
+	objects := []object.Object{}
+	for i, source := range sources {
+		name := fmt.Sprintf("module%d.asm", i)
+		obj, err := assembler.AssembleObject(strings.NewReader(source), name, assembler.Options{})
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		objects = append(objects, obj)
+	}
+	return objects
+}
+
+func TestLink(t *testing.T) {
+	tests := []struct {
+		sources       []string
+		expectedWords []uint16
+	}{
+		{
+			// labels are relocated by address of module, also in sums
+			[]string{"@i\nM=0\n", "(LOOP)\n@LOOP+1\n@LOOP\n0;JMP\n"},
+			[]uint16{16, 0b1110101010001000, 3, 2, 0b1110101010000111},
+		},
+		{
+			// exported label is used by module importing it, every module has its own variables
+			[]string{".import main\n@main\n0;JMP\n@x\n", ".export main\n(loop)\n@y\n(main)\n@x\n@loop\n"},
+			[]uint16{4, 0b1110101010000111, 16, 17, 18, 3},
+		},
+		{
+			// label which is not exported is private, its name means a variable elsewhere
+			[]string{".export start\n(start)\n(loop)\n@loop\n", ".import start\n@loop\n@start+1\n@SCREEN+1\n"},
+			[]uint16{0, 16, 1, 16385},
+		},
+	}
+
+	for i, tt := range tests {
+		program, err := Link(assembleObjects(t, tt.sources...), assembler.DefaultTarget())
+		if err != nil {
+			t.Fatalf("tests[%d]: %v", i, err)
+		}
+		if !reflect.DeepEqual(program.Words, tt.expectedWords) {
+			t.Fatalf("tests[%d]: expected=%v, but got=%v", i, tt.expectedWords, program.Words)
+		}
+	}
+}
+
+// variables of the same name in two modules are two variables, named after their module
+func TestLinkModuleVariables(t *testing.T) {
+	program, err := Link(assembleObjects(t, "@i\n@j\n", "@i\n"), assembler.DefaultTarget())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(program.Words, []uint16{16, 17, 18}) {
+		t.Fatalf("expected=[16 17 18], but got=%v", program.Words)
+	}
+	for symbol, address := range map[string]int{"module0.asm:i": 16, "j": 17, "module1.asm:i": 18} {
+		if !program.Symbols.Contains(symbol) || program.Symbols.GetAddress(symbol) != address {
+			t.Fatalf("expected symbol %v at %d", symbol, address)
+		}
+	}
+}
+
+func TestLinkErrors(t *testing.T) {
+	target, err := assembler.ReadTarget(strings.NewReader("rom 4\nvariables 16 16\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		sources     []string
+		expectedErr string
+	}{
+		{[]string{"(Foo.loop)\n@Foo.loop\n", "(Foo.loop)\n@Foo.loop\n"}, "module1.asm: label Foo.loop is already exported by module0.asm"},
+		{[]string{".import Sys.init\n@Sys.init\n"}, "module0.asm: imported label Sys.init is not exported by any module"},
+		{[]string{"@1\n(Foo.start)\n", ".import Foo.start\n@Foo.start-2\n"}, "module1.asm: instruction 0 loads -1, which is not in 0 to 32767"},
+		{[]string{"(Foo.count)\n", "@Foo.count\nM=0\n"}, "module1.asm: variable Foo.count is named like label exported by module0.asm"},
+		{[]string{"@a\n", "@b\n"}, "module1.asm: no RAM left for variable"},
+		{[]string{"@0\n@1\n@2\n", "@3\n@4\n"}, "program has 5 instructions, but ROM holds 4"},
+	}
+
+	for i, tt := range tests {
+		_, err := Link(assembleObjects(t, tt.sources...), target)
+		if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+			t.Fatalf("tests[%d]: expected error %q, but got=%v", i, tt.expectedErr, err)
+		}
+	}
+}
+
+// VM translator numbers labels of comparisons TRUE_n and DONE_n from 0 in every
+// module, modules translated separately are linked with their own labels.
+func TestLinkTranslatedModules(t *testing.T) {
+	// eq of VM translator, as in module of every .vm file
+	eq := strings.Join([]string{
+		"@SP", "AM=M-1", "D=M", "A=A-1", "D=M-D", "@TRUE_0", "D;JEQ", "D=0", "@DONE_0", "0;JMP",
+		"(TRUE_0)", "D=-1", "(DONE_0)", "@SP", "A=M-1", "M=D",
+	}, "\n")
+	main := ".import Math.abs\n(Main.main)\n" + eq + "\n@Math.abs\n0;JMP\n(Main.main$ret.0)\n@Main.main$ret.0\n0;JMP\n"
+	math := ".import Main.main$ret.0\n(Math.abs)\n" + eq + "\n@Main.main$ret.0\n0;JMP\n"
+	objects := assembleObjects(t, main, math)
+	program, err := Link(objects, assembler.DefaultTarget())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// @TRUE_0 and @DONE_0 are 5th and 8th instruction of each module
+	mathBase := len(objects[0].Code)
+	expected := map[int]uint16{5: 10, 8: 11, mathBase + 5: uint16(mathBase) + 10, mathBase + 8: uint16(mathBase) + 11}
+	for address, word := range expected {
+		if program.Words[address] != word {
+			t.Fatalf("ROM[%d]: expected=%d, but got=%d", address, word, program.Words[address])
+		}
+	}
+	if program.Words[14] != uint16(mathBase) || program.Words[mathBase+14] != 16 {
+		t.Fatalf("expected calls to be linked, but got=%v", program.Words)
+	}
+	if program.Symbols.Contains("TRUE_0") {
+		t.Fatalf("expected TRUE_0 to stay private in its module")
+	}
+}
+
+// program split into modules links into the same machine code as the whole program.
+func TestLinkSplitProgram(t *testing.T) {
+	source, err := os.ReadFile("../../../pong/Pong.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	whole, err := assembler.Assemble(strings.NewReader(string(source)), "Pong.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// split where functions of another class start, so that statics of a
+	// class, eg: math.0, stay variables of one module. Labels name their
+	// class before the dot, eg: math.init or LOOP_math.multiply
+	lines := strings.SplitAfter(string(source), "\n")
+	parts, start, class := []string{}, 0, ""
+	for i, line := range lines {
+		label := strings.Trim(strings.TrimSpace(line), "()")
+		name, _, ok := strings.Cut(label, ".")
+		if !strings.HasPrefix(line, "(") || !ok {
+			continue
+		}
+		name = name[strings.LastIndex(name, "_")+1:]
+		if name != class && i-start > len(lines)/4 {
+			parts = append(parts, strings.Join(lines[start:i], ""))
+			start = i
+		}
+		class = name
+	}
+	parts = append(parts, strings.Join(lines[start:], ""))
+	if len(parts) < 3 {
+		t.Fatalf("expected Pong.asm to be split into at least 3 modules, but got=%d", len(parts))
+	}
+	// labels without a dot are private unless exported, labels of other
+	// modules are imported
+	module := map[string]int{} // key: label, value: part declaring it
+	for i, part := range parts {
+		for _, line := range strings.Split(part, "\n") {
+			if line = strings.TrimSpace(line); strings.HasPrefix(line, "(") {
+				module[strings.Trim(line, "()")] = i
+			}
+		}
+	}
+	for i, part := range parts {
+		declarations, imported := "", map[string]bool{}
+		for _, line := range strings.Split(part, "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "(") {
+				declarations += ".export " + strings.Trim(line, "()") + "\n"
+			}
+			symbol := strings.TrimPrefix(line, "@")
+			if at, ok := module[symbol]; ok && strings.HasPrefix(line, "@") && at != i && !imported[symbol] {
+				declarations += ".import " + symbol + "\n"
+				imported[symbol] = true
+			}
+		}
+		parts[i] = declarations + part
+	}
+
+	objects := assembleObjects(t, parts...)
+	for i, obj := range objects {
+		var buf strings.Builder
+		if err := object.Write(&buf, obj); err != nil {
+			t.Fatal(err)
+		}
+		objects[i], err = object.Read(strings.NewReader(buf.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	linked, err := Link(objects, assembler.DefaultTarget())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(linked.Words, whole.Words) {
+		t.Fatalf("linked program of %d modules does not match assembled Pong.asm", len(objects))
+	}
+}
@@ -0,0 +1,348 @@
+package lsp
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ishwar00/HackAssembler/assembler"
+	"github.com/ishwar00/HackAssembler/errHandler"
+)
+
+// document is an open .asm file with the result of its last analysis.
+type document struct {
+	uri     string
+	path    string // file path of uri, source is named by it
+	text    string
+	program assembler.Program
+	diags   []errhandler.Error
+}
+
+// occurrence of a symbol in source
+type occurrence struct {
+	name        string
+	line        int // starts from 0
+	column      int
+	declaration bool // (label) or .equ name
+}
+
+// colors are not wanted in editor, they are off unless output is a terminal
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// assembles text of document, include opens files named by .include.
+func (doc *document) analyze(include func(path string) (io.ReadCloser, error)) {
+	opts := assembler.Options{Include: include}
+	program, diags, err := assembler.Analyze(strings.NewReader(doc.text), doc.path, opts)
+	if err != nil {
+		diags = []errhandler.Error{{ErrMsg: err.Error(), File: doc.path}}
+	}
+	doc.program, doc.diags = program, diags
+}
+
+// returns diagnostics of the analysis, key: uri of file they are in,
+// files included by document may have diagnostics too.
+func (doc *document) diagnostics() map[string][]Diagnostic {
+	diagnostics := map[string][]Diagnostic{doc.uri: {}}
+	for _, e := range doc.diags {
+		severity := SEVERITY_ERROR
+		if e.Warning {
+			severity = SEVERITY_WARNING
+		}
+		uri := doc.uri
+		if e.File != doc.path {
+			uri = pathToURI(e.File)
+		}
+		diagnostics[uri] = append(diagnostics[uri], Diagnostic{
+			Range: Range{
+				Start: Position{Line: e.OnLine, Character: e.OnColumn},
+				End:   Position{Line: e.OnLine, Character: e.OnColumn + e.Length},
+			},
+			Severity: severity,
+			Source:   "hackasm",
+			Message:  ansiEscape.ReplaceAllString(e.ErrMsg, ""),
+		})
+	}
+	return diagnostics
+}
+
+// returns occurrence of symbol at pos, false if there is none.
+func (doc *document) symbolAt(pos Position) (occurrence, bool) {
+	lines := strings.Split(doc.text, "\n")
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return occurrence{}, false
+	}
+	for _, o := range occurrences(lines[pos.Line], pos.Line) {
+		if o.column <= pos.Character && pos.Character <= o.column+len(o.name) {
+			return o, true
+		}
+	}
+	return occurrence{}, false
+}
+
+// returns where symbol is declared, a label by (label), a constant by .equ and
+// a variable by its first use. Predefined symbols are declared nowhere.
+func (doc *document) definition(name string) (Location, bool) {
+	site, ok := doc.program.Symbols.DefinedAt(name)
+	if !ok {
+		return Location{}, false
+	}
+	column := 0
+	if lines := doc.program.Source[site.File]; site.Line-1 < len(lines) {
+		found := occurrences(lines[site.Line-1], site.Line-1)
+		sort.SliceStable(found, func(i, j int) bool {
+			return found[i].declaration && !found[j].declaration
+		})
+		for _, o := range found {
+			if o.name == name {
+				column = o.column
+				break
+			}
+		}
+	}
+	start := Position{Line: site.Line - 1, Character: column}
+	end := Position{Line: site.Line - 1, Character: column + len(name)}
+	return Location{URI: doc.uriOf(site.File), Range: Range{start, end}}, true
+}
+
+// returns every occurrence of symbol in document and files it includes,
+// declarations only if withDeclaration is set.
+func (doc *document) references(name string, withDeclaration bool) []Location {
+	files := make([]string, 0, len(doc.program.Source))
+	for file := range doc.program.Source {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	if len(files) == 0 {
+		files = []string{doc.path} // source could not be read
+	}
+
+	locations := []Location{}
+	for _, file := range files {
+		lines := doc.program.Source[file]
+		if file == doc.path {
+			lines = strings.Split(doc.text, "\n")
+		}
+		for i, line := range lines {
+			for _, o := range occurrences(line, i) {
+				if o.name != name || (o.declaration && !withDeclaration) {
+					continue
+				}
+				start := Position{Line: i, Character: o.column}
+				end := Position{Line: i, Character: o.column + len(name)}
+				locations = append(locations, Location{URI: doc.uriOf(file), Range: Range{start, end}})
+			}
+		}
+	}
+	return locations
+}
+
+// returns markdown describing symbol at pos and machine code of instruction
+// on line of pos, "" if there is nothing to tell.
+func (doc *document) hover(pos Position) string {
+	parts := []string{}
+	if o, ok := doc.symbolAt(pos); ok {
+		if description := doc.describe(o.name); description != "" {
+			parts = append(parts, description)
+		}
+	}
+
+	// addresses are known only if every instruction is assembled
+	for _, e := range doc.diags {
+		if !e.Warning {
+			return strings.Join(parts, "\n\n")
+		}
+	}
+	encodings := []string{}
+	for address, instrInfo := range doc.program.Instrs {
+		if instrInfo.InFile == doc.path && instrInfo.AtLine == pos.Line+1 {
+			word := doc.program.Words[address]
+			encodings = append(encodings, fmt.Sprintf("ROM[%d]  %016b  %04X  %v", address, word, word, instrInfo.Instr))
+		}
+	}
+	if len(encodings) > 0 {
+		parts = append(parts, "```\n"+strings.Join(encodings, "\n")+"\n```")
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// eg: **LOOP** label, ROM[12]
+func (doc *document) describe(name string) string {
+	st := doc.program.Symbols
+	if !st.Contains(name) {
+		return ""
+	}
+	address := st.GetAddress(name)
+	switch st.Kind(name) {
+	case assembler.LABEL_SYMBOL:
+		return fmt.Sprintf("**%v** label, ROM[%d]", name, address)
+	case assembler.VARIABLE_SYMBOL:
+		return fmt.Sprintf("**%v** variable, RAM[%d]", name, address)
+	case assembler.CONSTANT_SYMBOL:
+		return fmt.Sprintf("**%v** constant, %d (0x%04X)", name, address, address)
+	default:
+		return fmt.Sprintf("**%v** predefined, RAM[%d]", name, address)
+	}
+}
+
+// returns completions at pos, symbols after @, otherwise mnemonics of the
+// C instruction field being written.
+func (doc *document) completion(pos Position) []CompletionItem {
+	lines := strings.Split(doc.text, "\n")
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return []CompletionItem{}
+	}
+	line := lines[pos.Line]
+	before := strings.TrimSpace(line[:min(pos.Character, len(line))])
+	if strings.Contains(before, "//") {
+		return []CompletionItem{}
+	}
+
+	items := []CompletionItem{}
+	if strings.HasPrefix(before, "@") {
+		st := doc.program.Symbols
+		kinds := []struct {
+			kind       int
+			itemKind   int
+			name, unit string
+		}{
+			{assembler.LABEL_SYMBOL, KIND_LABEL, "label", "ROM"},
+			{assembler.VARIABLE_SYMBOL, KIND_VARIABLE, "variable", "RAM"},
+			{assembler.CONSTANT_SYMBOL, KIND_CONSTANT, "constant", ""},
+			{assembler.PREDEFINED_SYMBOL, KIND_VARIABLE, "predefined", "RAM"},
+		}
+		for _, k := range kinds {
+			for _, symbol := range st.Symbols(k.kind) {
+				detail := fmt.Sprintf("%v %v[%d]", k.name, k.unit, st.GetAddress(symbol))
+				if k.unit == "" {
+					detail = fmt.Sprintf("%v %d", k.name, st.GetAddress(symbol))
+				}
+				items = append(items, CompletionItem{Label: symbol, Kind: k.itemKind, Detail: detail})
+			}
+		}
+		return items
+	}
+
+	var code assembler.Code
+	code.Initialize()
+	add := func(mnemonics []string, detail string) {
+		for _, mnemonic := range mnemonics {
+			items = append(items, CompletionItem{Label: mnemonic, Kind: KIND_KEYWORD, Detail: detail})
+		}
+	}
+	switch {
+	case strings.Contains(before, ";"):
+		add(code.JumpMnemonics(), "jump")
+	case strings.Contains(before, "="):
+		add(code.CompMnemonics(), "comp")
+	case strings.HasPrefix(before, "(") || strings.HasPrefix(before, "."):
+		// label declaration or directive, nothing to complete
+	default:
+		add(code.DestMnemonics(), "dest")
+		add(code.CompMnemonics(), "comp")
+	}
+	return items
+}
+
+// returns uri of file named in source, which is document itself or a file it includes.
+func (doc *document) uriOf(file string) string {
+	if file == doc.path {
+		return doc.uri
+	}
+	return pathToURI(file)
+}
+
+// returns symbols on line: label declared by (label), symbols loaded by A
+// instruction, constant defined by .equ, names in .export and .import and
+// arguments of macro invocations. Lines of C instructions have no symbols.
+func occurrences(line string, atLine int) []occurrence {
+	code := line
+	if at := strings.Index(code, "//"); at != -1 {
+		code = code[:at]
+	}
+	trimmed := strings.TrimSpace(code)
+	start := strings.Index(code, trimmed) // column of first character of instruction
+
+	declaration := false
+	switch fields := strings.Fields(trimmed); {
+	case len(fields) == 0:
+		return nil
+	case fields[0] == ".equ":
+		if len(fields) < 2 {
+			return nil
+		}
+		declaration = true
+		start += len(fields[0])
+		start += strings.Index(code[start:], fields[1])
+		code = code[:start+len(fields[1])] // value is not a symbol
+	case fields[0] == ".export" || fields[0] == ".import":
+		start += len(fields[0])
+	case strings.HasPrefix(trimmed, "."):
+		return nil // .macro, .include and .endm name no symbols
+	case trimmed[0] == '(':
+		declaration = true
+	case trimmed[0] == '@':
+	case strings.ContainsAny(trimmed, "=;"):
+		return nil
+	default:
+		start += len(fields[0]) // macro invocation, its arguments may be symbols
+	}
+
+	found := []occurrence{}
+	for i := start; i < len(code); {
+		if !isSymbolChar(code[i]) {
+			i++
+			continue
+		}
+		end := i
+		for end < len(code) && isSymbolChar(code[end]) {
+			end++
+		}
+		name := code[i:end]
+		// \param of macro body is not a symbol and literals start with a digit
+		if (i == 0 || code[i-1] != '\\') && assembler.IsSymbol(name) {
+			found = append(found, occurrence{name: name, line: atLine, column: i, declaration: declaration})
+		}
+		i = end
+	}
+	return found
+}
+
+func isSymbolChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte(".$:_", c) != -1
+}
+
+// file:///home/me/Prog.asm -> /home/me/Prog.asm
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	path := u.Path
+	// file:///C:/Prog.asm
+	if len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
+
+func pathToURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,260 @@
+// Package lsp is a language server for Hack assembly, it speaks Language
+// Server Protocol over JSON-RPC and offers diagnostics as you type,
+// go-to-definition of symbols, find-references, hover showing address and
+// machine code of instructions, and completion of symbols and mnemonics.
+//
+// Every change of a document assembles it again with assembler.Analyze,
+// so diagnostics are exactly what HackAssembler reports.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type server struct {
+	out       io.Writer
+	docs      map[string]*document // key: uri
+	published map[string][]string  // key: uri of document, value: uris its diagnostics are published for
+}
+
+// errors of handlers sent back to the client with their code
+type rpcError struct {
+	code int
+	msg  string
+}
+
+func (e *rpcError) Error() string {
+	return e.msg
+}
+
+// Serve answers requests read from r and writes responses and notifications
+// to w, until client sends exit notification or r ends.
+func Serve(r io.Reader, w io.Writer) error {
+	s := server{out: w, docs: map[string]*document{}, published: map[string][]string{}}
+	reader := bufio.NewReader(r)
+	for {
+		content, err := readMessage(reader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var msg message
+		if err := json.Unmarshal(content, &msg); err != nil {
+			if err := s.respond(nil, nil, &rpcError{PARSE_ERROR, err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+
+		result, err := s.handle(msg.Method, msg.Params)
+		if msg.ID == nil {
+			continue // notification has no response, even if it failed
+		}
+		if err := s.respond(msg.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+// handles request or notification, result is nil for notifications.
+func (s *server) handle(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "initialize":
+		var result InitializeResult
+		result.ServerInfo.Name = "hacklsp"
+		result.Capabilities.TextDocumentSync = SYNC_FULL
+		result.Capabilities.DefinitionProvider = true
+		result.Capabilities.ReferencesProvider = true
+		result.Capabilities.HoverProvider = true
+		result.Capabilities.CompletionProvider.TriggerCharacters = []string{"@", "=", ";"}
+		return result, nil
+
+	case "initialized":
+		return nil, nil
+
+	case "shutdown":
+		return nil, nil // nothing to clean up, exit follows
+
+	case "textDocument/didOpen":
+		var p DidOpenTextDocumentParams
+		if err := unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		doc := &document{uri: p.TextDocument.URI, path: uriToPath(p.TextDocument.URI), text: p.TextDocument.Text}
+		s.docs[doc.uri] = doc
+		return nil, s.update(doc)
+
+	case "textDocument/didChange":
+		var p DidChangeTextDocumentParams
+		if err := unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		doc, ok := s.docs[p.TextDocument.URI]
+		if !ok || len(p.ContentChanges) == 0 {
+			return nil, nil
+		}
+		doc.text = p.ContentChanges[len(p.ContentChanges)-1].Text // sync is full, last change is the document
+		return nil, s.update(doc)
+
+	case "textDocument/didClose":
+		var p DidCloseTextDocumentParams
+		if err := unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		delete(s.docs, p.TextDocument.URI)
+		err := s.publish(p.TextDocument.URI, map[string][]Diagnostic{})
+		delete(s.published, p.TextDocument.URI)
+		return nil, err
+
+	case "textDocument/definition":
+		var p TextDocumentPositionParams
+		doc, err := s.document(params, &p, &p.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		if o, ok := doc.symbolAt(p.Position); ok {
+			if location, ok := doc.definition(o.name); ok {
+				return location, nil
+			}
+		}
+		return nil, nil
+
+	case "textDocument/references":
+		var p ReferenceParams
+		doc, err := s.document(params, &p, &p.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		if o, ok := doc.symbolAt(p.Position); ok {
+			return doc.references(o.name, p.Context.IncludeDeclaration), nil
+		}
+		return []Location{}, nil
+
+	case "textDocument/hover":
+		var p TextDocumentPositionParams
+		doc, err := s.document(params, &p, &p.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		if contents := doc.hover(p.Position); contents != "" {
+			return Hover{Contents: MarkupContent{Kind: "markdown", Value: contents}}, nil
+		}
+		return nil, nil
+
+	case "textDocument/completion":
+		var p TextDocumentPositionParams
+		doc, err := s.document(params, &p, &p.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return doc.completion(p.Position), nil
+	}
+
+	if strings.HasPrefix(method, "$/") {
+		return nil, nil // optional notifications like $/cancelRequest may be ignored
+	}
+	return nil, &rpcError{METHOD_NOT_FOUND, fmt.Sprintf("method %v is not supported", method)}
+}
+
+// decodes params into p and returns open document named by its TextDocument.
+func (s *server) document(params json.RawMessage, p interface{}, id *TextDocumentIdentifier) (*document, error) {
+	if err := unmarshal(params, p); err != nil {
+		return nil, err
+	}
+	doc, ok := s.docs[id.URI]
+	if !ok {
+		return nil, &rpcError{INVALID_PARAMS, fmt.Sprintf("document %v is not open", id.URI)}
+	}
+	return doc, nil
+}
+
+// analyzes doc again and publishes its diagnostics.
+func (s *server) update(doc *document) error {
+	doc.analyze(s.include)
+	return s.publish(doc.uri, doc.diagnostics())
+}
+
+// publishes diagnostics of document at uri, files which had diagnostics
+// before, but have none now, are cleared.
+func (s *server) publish(uri string, diagnostics map[string][]Diagnostic) error {
+	for _, file := range s.published[uri] {
+		if _, ok := diagnostics[file]; !ok {
+			diagnostics[file] = []Diagnostic{}
+		}
+	}
+	files := []string{}
+	for file := range diagnostics {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		err := s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: file, Diagnostics: diagnostics[file]})
+		if err != nil {
+			return err
+		}
+	}
+	s.published[uri] = files
+	return nil
+}
+
+// opens file named by .include, text of open document is used instead of
+// the file, so unsaved edits of included files are seen.
+func (s *server) include(path string) (io.ReadCloser, error) {
+	abs, err := filepath.Abs(path)
+	if err == nil {
+		if doc, ok := s.docs[pathToURI(abs)]; ok {
+			return io.NopCloser(strings.NewReader(doc.text)), nil
+		}
+	}
+	return os.Open(path)
+}
+
+func (s *server) respond(id *json.RawMessage, result interface{}, err error) error {
+	msg := message{ID: id}
+	if id == nil {
+		null := json.RawMessage("null")
+		msg.ID = &null // response to request which could not be parsed
+	}
+	if err != nil {
+		e := &rpcError{code: INVALID_PARAMS, msg: err.Error()}
+		errors.As(err, &e)
+		msg.Error = &responseError{Code: e.code, Message: e.msg}
+		return writeMessage(s.out, msg)
+	}
+
+	content, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	msg.Result = content
+	return writeMessage(s.out, msg)
+}
+
+func (s *server) notify(method string, params interface{}) error {
+	content, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return writeMessage(s.out, message{Method: method, Params: content})
+}
+
+func unmarshal(params json.RawMessage, p interface{}) error {
+	if err := json.Unmarshal(params, p); err != nil {
+		return &rpcError{INVALID_PARAMS, err.Error()}
+	}
+	return nil
+}
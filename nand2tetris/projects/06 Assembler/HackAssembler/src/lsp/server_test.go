@@ -0,0 +1,203 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runs server on requests, key "id" makes a request, without it a notification,
+// returns responses and notifications written by server.
+func serve(t *testing.T, requests ...map[string]interface{}) []message {
+	t.Helper()
+	var in, out bytes.Buffer
+	for _, request := range requests {
+		request["jsonrpc"] = "2.0"
+		content, err := json.Marshal(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(content), content)
+	}
+	if err := Serve(&in, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := []message{}
+	reader := bufio.NewReader(&out)
+	for reader.Buffered() > 0 || out.Len() > 0 {
+		content, err := readMessage(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var msg message
+		if err := json.Unmarshal(content, &msg); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+// returns result of response to request id, fails if it is an error.
+func result(t *testing.T, messages []message, id int, v interface{}) {
+	t.Helper()
+	for _, msg := range messages {
+		if msg.ID != nil && string(*msg.ID) == fmt.Sprint(id) {
+			if msg.Error != nil {
+				t.Fatalf("request %d: %v", id, msg.Error.Message)
+			}
+			if err := json.Unmarshal(msg.Result, v); err != nil {
+				t.Fatal(err)
+			}
+			return
+		}
+	}
+	t.Fatalf("request %d has no response", id)
+}
+
+func position(uri string, line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri},
+		"position":     map[string]int{"line": line, "character": character},
+	}
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	prog := pathToURI(filepath.Join(dir, "Prog.asm"))
+	lib := pathToURI(filepath.Join(dir, "Lib.asm"))
+	progText := `.include "Lib.asm"
+@i
+M=0
+(LOOP)
+	@i
+	M=M+1
+	@LOOP
+	0;JMP
+	@Lib.start
+	D;
+`
+	open := func(uri, text string) map[string]interface{} {
+		return map[string]interface{}{
+			"method": "textDocument/didOpen",
+			"params": map[string]interface{}{"textDocument": map[string]string{"uri": uri, "text": text}},
+		}
+	}
+
+	messages := serve(t,
+		map[string]interface{}{"id": 1, "method": "initialize", "params": map[string]interface{}{}},
+		// included file is open, its unsaved text is used
+		open(lib, "(Lib.start)\n@Lib.start\n0;JMP\n"),
+		open(prog, progText),
+		map[string]interface{}{"id": 2, "method": "textDocument/definition", "params": position(prog, 6, 3)},
+		map[string]interface{}{"id": 3, "method": "textDocument/definition", "params": position(prog, 8, 4)},
+		map[string]interface{}{"id": 4, "method": "textDocument/references", "params": map[string]interface{}{
+			"textDocument": map[string]string{"uri": prog},
+			"position":     map[string]int{"line": 1, "character": 1},
+			"context":      map[string]bool{"includeDeclaration": true},
+		}},
+		map[string]interface{}{"id": 5, "method": "textDocument/hover", "params": position(prog, 6, 2)},
+		map[string]interface{}{"id": 6, "method": "textDocument/completion", "params": position(prog, 6, 2)},
+		map[string]interface{}{"id": 7, "method": "textDocument/completion", "params": position(prog, 9, 3)},
+		map[string]interface{}{"id": 8, "method": "textDocument/formatting", "params": position(prog, 0, 0)},
+		map[string]interface{}{"id": 9, "method": "shutdown"},
+		map[string]interface{}{"method": "exit"},
+	)
+
+	var initialized InitializeResult
+	result(t, messages, 1, &initialized)
+	if !initialized.Capabilities.HoverProvider || initialized.Capabilities.TextDocumentSync != SYNC_FULL {
+		t.Fatalf("expected hover and full sync, but got=%+v", initialized.Capabilities)
+	}
+
+	var definition Location
+	result(t, messages, 2, &definition)
+	if definition.URI != prog || definition.Range.Start != (Position{3, 1}) {
+		t.Fatalf("expected (LOOP) at 3:1, but got=%+v", definition)
+	}
+	result(t, messages, 3, &definition)
+	if definition.URI != lib || definition.Range.Start != (Position{0, 1}) {
+		t.Fatalf("expected (Lib.start) in Lib.asm at 0:1, but got=%+v", definition)
+	}
+
+	var references []Location
+	result(t, messages, 4, &references)
+	lines := []int{}
+	for _, reference := range references {
+		lines = append(lines, reference.Range.Start.Line)
+	}
+	if fmt.Sprint(lines) != "[1 4]" {
+		t.Fatalf("expected references of i on lines [1 4], but got=%v", references)
+	}
+
+	var hover Hover
+	result(t, messages, 5, &hover)
+	for _, expected := range []string{"**LOOP** label, ROM[4]", "ROM[6]  0000000000000100  0004  @LOOP"} {
+		if !strings.Contains(hover.Contents.Value, expected) {
+			t.Fatalf("expected hover to contain %q, but got=%q", expected, hover.Contents.Value)
+		}
+	}
+
+	for id, expected := range map[int]string{6: "LOOP", 7: "JGT"} {
+		var items []CompletionItem
+		result(t, messages, id, &items)
+		found := false
+		for _, item := range items {
+			found = found || item.Label == expected
+		}
+		if !found {
+			t.Fatalf("request %d: expected completion %v, but got=%v", id, expected, items)
+		}
+	}
+
+	for _, msg := range messages {
+		if msg.ID != nil && string(*msg.ID) == "8" {
+			if msg.Error == nil || msg.Error.Code != METHOD_NOT_FOUND {
+				t.Fatalf("expected method not found, but got=%+v", msg)
+			}
+		}
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	uri := pathToURI(filepath.Join(t.TempDir(), "Prog.asm"))
+	text := func(text string) map[string]interface{} {
+		return map[string]interface{}{"textDocument": map[string]string{"uri": uri}, "contentChanges": []map[string]string{{"text": text}}}
+	}
+	messages := serve(t,
+		map[string]interface{}{"method": "textDocument/didOpen", "params": map[string]interface{}{
+			"textDocument": map[string]string{"uri": uri, "text": "@R16\nD=Q\n"},
+		}},
+		map[string]interface{}{"method": "textDocument/didChange", "params": text("@x\nD=M\n")},
+	)
+
+	published := []PublishDiagnosticsParams{}
+	for _, msg := range messages {
+		if msg.Method != "textDocument/publishDiagnostics" {
+			t.Fatalf("expected diagnostics only, but got=%+v", msg)
+		}
+		var p PublishDiagnosticsParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			t.Fatal(err)
+		}
+		published = append(published, p)
+	}
+	if len(published) != 2 {
+		t.Fatalf("expected diagnostics for open and change, but got=%+v", published)
+	}
+
+	diagnostics := published[0].Diagnostics
+	if len(diagnostics) != 1 || diagnostics[0].Severity != SEVERITY_ERROR ||
+		diagnostics[0].Range != (Range{Position{1, 2}, Position{1, 3}}) ||
+		!strings.HasPrefix(diagnostics[0].Message, "unknown comp Q") {
+		t.Fatalf("expected unknown comp error at 1:2, but got=%+v", diagnostics)
+	}
+	if len(published[1].Diagnostics) != 0 {
+		t.Fatalf("expected fixed document to have no diagnostics, but got=%+v", published[1].Diagnostics)
+	}
+}
@@ -1,50 +1,285 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/fatih/color"
+	"github.com/ishwar00/HackAssembler/assembler"
+	"github.com/ishwar00/HackAssembler/files"
+	"github.com/ishwar00/HackAssembler/formats"
+	"github.com/ishwar00/HackAssembler/object"
 )
 
 const doc = `
- HackAssembler example/path/prog1.asm example1/path1/prog2.asm ...
- 
+ HackAssembler [flags] example/path/prog1.asm example1/path1/prog2.asm ...
+ HackAssembler [flags] projects/06 'projects/0[45]/*/*.asm'
+ cat Prog.asm | HackAssembler - > Prog.hack
+
  When given to your assembler as a command line argument, one or more
  progi.asm file containing a Hack assembly language program, it will be
  translated into the correct Hack binary code and stored in a file named
  Progi.hack, located in the same folder as the source file \n
- (if a file by this name exists, it is overwritten).
- `
+ (if a file by this name exists, it is overwritten). With -format the
+ binary code is written in another format, in a file with that format's
+ extension, eg: Progi.mif for -format=mif. Output files are written
+ atomically, a failed run never leaves a truncated file. With -o the output
+ file is named explicitly, with -outdir it is put in another directory, and
+ - as source reads standard input and writes machine code to standard output.
+
+ Arguments may also be directories, which are searched recursively for .asm
+ files, and glob patterns. Files are assembled concurrently and a summary
+ table is printed at the end, exit status is 1 if any file failed.
+
+ A instructions may load hex @0x4000 or binary @0b1010 literals and sums of
+ literals and symbols like @SCREEN+32 or @LOOP-1, value must fit in 15 bits.
+
+ Besides Hack assembly, source may use directives .macro NAME params ... .endm,
+ .equ NAME value and .include "file.asm", see assembler.Preprocessor.
+
+ With -c each source is assembled into a relocatable object Progi.hobj
+ instead, objects are combined into a program by hacklink, eg: the OS is
+ assembled once and linked with every application.
+
+ flags:
+`
+
+var format = flag.String("format", "hack",
+	"output format of machine code, one of "+strings.Join(formats.Names(), ", "))
+
+var strict = flag.Bool("strict", false,
+	"accept only documented comp and dest mnemonics, as the course does, instead of\nalso accepting commuted spellings (A+D), extended ALU functions (D&!A) and raw bits (%B0000001)")
+
+var listing = flag.Bool("listing", false,
+	"also write Progi.lst listing ROM address, binary, hex and source line\nof every instruction followed by resolved symbol table")
+
+var optimize = flag.Bool("O", false,
+	"remove instructions which do not change what program does: dead A-loads,\nrepeated loads, M=M+1 M=M-1 pairs and unreachable code after 0;JMP")
+
+var werror = flag.Bool("Werror", false,
+	"treat warnings as errors: undeclared labels used as jump targets, variables like R16,\nAM=D+1 style writes, jumps after A is rewritten and unused labels")
+
+var jobs = flag.Int("j", runtime.NumCPU(), "number of files assembled concurrently")
+
+var output = flag.String("o", "", "write machine code to this file, - for standard output, only for a single source")
+
+var outdir = flag.String("outdir", "", "write output files into this directory instead of next to sources")
+
+var stream = flag.Bool("stream", false,
+	"assemble in a single pass while reading source, for large generated programs,\nit uses less memory, but directives, -O, -listing and warnings are not available")
+
+var symbols = flag.String("symbols", "",
+	"export symbol table with addresses and source lines to this file, as JSON if it ends\nwith .json, otherwise as .sym text, an extension alone like .json names it after source")
+
+var targetPath = flag.String("target", "",
+	"file describing modified Hack machine: predefined symbols, RAM for variables and ROM size,\nsee assembler.ReadTarget")
+
+var compile = flag.Bool("c", false,
+	"write relocatable object Progi.hobj for hacklink instead of machine code, labels listed by\n.export, or labels with a dot if there is none, eg: Foo.bar, may be used by other objects")
+
+// machine programs are assembled for, nil for standard Hack
+var target *assembler.Target
+
+// messages and summary are written here, it is standard error
+// when machine code is written to standard output
+var console io.Writer = os.Stdout
 
 func main() {
-	// filePaths
-	// 	"../../add/Add.asm"
-	// 	"../../max/Max.asm"
-	// 	"../../max/MaxL.asm"
-	// 	"../../pong/Pong.asm"
-	// 	"../../pong/PongL.asm"
-	// 	"../../rect/Rect.asm"
-	// 	"../../rect/RectL.asm"
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), doc)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	outputFormat, ok := formats.Lookup(*format)
+	if !ok {
+		fail("unknown format %v, expected one of %v", *format, strings.Join(formats.Names(), ", "))
+	}
+
+	if flag.NArg() == 0 {
+		fail("program needs arguments as .asm file paths, directories or globs, - for standard input, run with flag --help")
+	}
+
+	filePaths, err := expandPaths(flag.Args())
+	if err != nil {
+		fail("%v", err)
+	}
+	if len(filePaths) == 0 {
+		fail("no .asm files found in %v", strings.Join(flag.Args(), " "))
+	}
+
+	switch {
+	case *output != "" && *outdir != "":
+		fail("-o and -outdir can not be used together")
+	case *stream && (*optimize || *listing || *werror):
+		fail("-stream can not be used with -O, -listing or -Werror, they need the whole program")
+	case *compile && (*stream || *optimize || *listing || *symbols != ""):
+		fail("-c can not be used with -stream, -O, -listing or -symbols, they need the linked program")
+	case *symbols != "" && !isExtension(*symbols) && len(filePaths) > 1:
+		fail("-symbols %v needs a single source, use an extension like -symbols .json instead", *symbols)
+	case *output != "" && len(filePaths) > 1:
+		fail("-o needs a single source, but %d were given, use -outdir instead", len(filePaths))
+	}
+	if *outdir != "" {
+		if err := os.MkdirAll(*outdir, 0o755); err != nil {
+			fail("%v", err)
+		}
+	}
+	if *targetPath != "" {
+		t, err := files.ReadTarget(*targetPath)
+		if err != nil {
+			fail("%v: %v", *targetPath, err)
+		}
+		target = &t
+	}
+	extension := outputFormat.Extension()
+	if *compile {
+		extension = object.Extension
+	}
+	for _, filePath := range filePaths {
+		if codePath(filePath, extension, *output, *outdir) == stdio {
+			console = os.Stderr
+		}
+	}
+	err = checkOutputPaths(filePaths, func(filePath string) []string {
+		return outputPaths(filePath, extension)
+	})
+	if err != nil {
+		fail("%v", err)
+	}
+
+	results := assembleAll(filePaths, *jobs, func(filePath string, log io.Writer) fileResult {
+		return assembleFile(filePath, outputFormat, log)
+	})
+	for _, result := range results {
+		fmt.Fprint(console, result.report)
+	}
+
+	failed := writeSummary(console, results)
+	if failed > 0 {
+		fail("terminating assembler...")
+	}
+}
 
-	if len(os.Args) == 1 {
-		err := fmt.Errorf(color.RedString("program needs arguments as .asm file paths, run with flag --help"))
-		panic(err)
+// reports error on console and exits with status 1
+func fail(format string, a ...interface{}) {
+	fmt.Fprintln(console, color.RedString(format, a...))
+	os.Exit(1)
+}
+
+// assembles the .asm file at filePath and writes machine code in outputFormat
+// next to it, output file is not created if source has any error. Messages are
+// written to log, so that files assembled concurrently do not mix their output.
+func assembleFile(filePath string, outputFormat formats.Format, log io.Writer) fileResult {
+	result := fileResult{path: filePath}
+	fmt.Fprintln(log, color.GreenString("assembling %v...", filePath))
+
+	program, err := assembleProgram(filePath, outputFormat)
+	if err != nil {
+		fmt.Fprintln(log, err)
+		fmt.Fprintln(log, color.RedString("failed to assemble %v", filePath))
+		fmt.Fprintln(log, "")
+		result.err = err
+		return result
+	}
+	result.instructions = len(program.Words)
+	if program.Warnings != nil {
+		fmt.Fprintln(log, program.Warnings)
+		result.warnings = program.Warnings.Warning_count()
 	}
 
-	if len(os.Args) == 2 && strings.TrimSpace(os.Args[1]) == "--help" {
-		fmt.Println(doc)
-		return
+	TI := fmt.Sprint(len(program.Words))
+	fmt.Fprintln(log, color.GreenString("processed"), color.YellowString(TI), color.GreenString("instructions"))
+	if *optimize {
+		RI := fmt.Sprint(len(program.Removed))
+		fmt.Fprintln(log, color.GreenString("optimizer removed"), color.YellowString(RI), color.GreenString("instructions"))
 	}
+	fmt.Fprintln(log, color.GreenString("finished assembling..."))
+	fmt.Fprintln(log, "")
+	return result
+}
+
+// assembles source into relocatable object and writes it, returned program
+// has no machine code, only as many words as the object, for the summary.
+func assembleObject(source io.Reader, name, filePath string, opts assembler.Options) (assembler.Program, error) {
+	obj, err := assembler.AssembleObject(source, name, opts)
+	if err != nil {
+		return assembler.Program{}, err
+	}
+	objPath := codePath(filePath, object.Extension, *output, *outdir)
+	err = files.WriteFile(objPath, func(w io.Writer) error {
+		return object.Write(w, obj)
+	})
+	if err != nil {
+		return assembler.Program{}, err
+	}
+	return assembler.Program{Name: name, Words: make([]uint16, len(obj.Code))}, nil
+}
 
-	for _, filePath := range os.Args[1:] {
-		err := Assembler(filePath)
+// assembles program at filePath and writes its output files,
+// stdio as filePath reads program from standard input.
+func assembleProgram(filePath string, outputFormat formats.Format) (assembler.Program, error) {
+	source, name := io.Reader(os.Stdin), stdinName
+	if filePath != stdio {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return assembler.Program{}, err
+		}
+		defer file.Close()
+		source, name = file, filePath
+	}
+
+	opts := assembler.Options{Strict: *strict, Optimize: *optimize, Werror: *werror, Target: target}
+	if *compile {
+		return assembleObject(source, name, filePath, opts)
+	}
+	assemble := assembler.AssembleWith
+	if *stream {
+		assemble = assembler.AssembleStream
+	}
+	program, err := assemble(source, name, opts)
+	if err != nil {
+		return assembler.Program{}, err
+	}
+
+	hackPath := codePath(filePath, outputFormat.Extension(), *output, *outdir)
+	err = files.WriteFile(hackPath, func(w io.Writer) error {
+		return outputFormat.Write(w, program.Words)
+	})
+	if err != nil {
+		return assembler.Program{}, err
+	}
+
+	if *listing {
+		lstPath, err := listingPath(filePath, *output, *outdir)
+		if err != nil {
+			return assembler.Program{}, err
+		}
+		err = files.WriteFile(lstPath, func(w io.Writer) error {
+			return assembler.WriteListing(w, program)
+		})
+		if err != nil {
+			return assembler.Program{}, err
+		}
+	}
+
+	if *symbols != "" {
+		symPath := symbolsPath(filePath, *symbols, *outdir)
+		writeSymbols := assembler.WriteSymbols
+		if strings.EqualFold(filepath.Ext(symPath), ".json") {
+			writeSymbols = assembler.WriteSymbolsJSON
+		}
+		err = files.WriteFile(symPath, func(w io.Writer) error {
+			return writeSymbols(w, program)
+		})
 		if err != nil {
-			color.Red("terminating assembler...")
-			panic(err)
+			return assembler.Program{}, err
 		}
-		color.Green("finished assembling...")
-		fmt.Println("")
 	}
+	return program, nil
 }
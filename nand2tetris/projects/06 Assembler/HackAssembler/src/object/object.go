@@ -0,0 +1,182 @@
+// Package object is the relocatable object format of separately assembled
+// Hack modules, which are combined into one program by hacklink.
+//
+// Object is written as text, one entry per line, // starts a comment:
+//
+//	hackobj 1                   format and its version
+//	module Main.asm             name of module
+//	export Main.main 0          label other modules may use, with its offset in module
+//	import Sys.init             label which must be exported by another module
+//	extern i                    symbol neither defined nor imported by module,
+//	                            it is a variable of the module
+//	w 1110101010000111          instruction which needs no relocation
+//	a 4 +@                      A instruction loading 4 plus ROM address of module
+//	a -1 +Sys.init              A instruction loading address of Sys.init minus 1
+//
+// Value of "a" entry is its constant part, each following term adds (+) or
+// subtracts (-) address of a symbol, @ stands for ROM address of the module.
+package object
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const version = 1
+
+// Extension is the file extension of objects.
+const Extension = ".hobj"
+
+// Object is a separately assembled module.
+type Object struct {
+	Name    string
+	Code    []Word
+	Exports map[string]int // key: label, value: offset of label in module
+	Imports []string       // labels which must be exported by other modules
+	Externs []string       // symbols left to linker, variables of the module
+}
+
+// Word is an instruction of module, Value is the instruction itself,
+// unless it has Relocs, then it is constant part of A instruction operand.
+type Word struct {
+	Value  int
+	Relocs []Reloc
+}
+
+// Reloc adds (Sign 1) or subtracts (Sign -1) address of Symbol
+// from value of A instruction, empty Symbol is ROM address of the module.
+type Reloc struct {
+	Sign   int
+	Symbol string
+}
+
+// writes obj in object format.
+func Write(w io.Writer, obj Object) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "hackobj %d\n", version)
+	fmt.Fprintf(bw, "module %s\n", obj.Name)
+
+	exports := make([]string, 0, len(obj.Exports))
+	for label := range obj.Exports {
+		exports = append(exports, label)
+	}
+	sort.Slice(exports, func(i, j int) bool {
+		if obj.Exports[exports[i]] == obj.Exports[exports[j]] {
+			return exports[i] < exports[j]
+		}
+		return obj.Exports[exports[i]] < obj.Exports[exports[j]]
+	})
+	for _, label := range exports {
+		fmt.Fprintf(bw, "export %s %d\n", label, obj.Exports[label])
+	}
+	for _, label := range obj.Imports {
+		fmt.Fprintf(bw, "import %s\n", label)
+	}
+	for _, symbol := range obj.Externs {
+		fmt.Fprintf(bw, "extern %s\n", symbol)
+	}
+
+	for _, word := range obj.Code {
+		if len(word.Relocs) == 0 {
+			fmt.Fprintf(bw, "w %016b\n", uint16(word.Value))
+			continue
+		}
+		fmt.Fprintf(bw, "a %d", word.Value)
+		for _, reloc := range word.Relocs {
+			sign, symbol := "+", reloc.Symbol
+			if reloc.Sign < 0 {
+				sign = "-"
+			}
+			if symbol == "" {
+				symbol = "@"
+			}
+			fmt.Fprintf(bw, " %s%s", sign, symbol)
+		}
+		fmt.Fprintf(bw, "\n")
+	}
+	return bw.Flush()
+}
+
+// reads object written by Write.
+func Read(r io.Reader) (Object, error) {
+	obj := Object{Code: []Word{}, Exports: map[string]int{}, Imports: []string{}, Externs: []string{}}
+	scanner := bufio.NewScanner(r)
+	header := false
+	for atLine := 1; scanner.Scan(); atLine++ {
+		line := scanner.Text()
+		if at := strings.Index(line, "//"); at != -1 {
+			line = line[:at]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if !header {
+			if len(fields) != 2 || fields[0] != "hackobj" || fields[1] != strconv.Itoa(version) {
+				return Object{}, fmt.Errorf("line %d: not a hack object, expected hackobj %d", atLine, version)
+			}
+			header = true
+			continue
+		}
+
+		var err error
+		switch key := fields[0]; {
+		case key == "module":
+			obj.Name = strings.TrimSpace(strings.TrimSpace(line)[len(key):]) // name may have spaces
+		case key == "export" && len(fields) == 3:
+			obj.Exports[fields[1]], err = strconv.Atoi(fields[2])
+		case key == "import" && len(fields) == 2:
+			obj.Imports = append(obj.Imports, fields[1])
+		case key == "extern" && len(fields) == 2:
+			obj.Externs = append(obj.Externs, fields[1])
+		case key == "w" && len(fields) == 2:
+			var word uint64
+			word, err = strconv.ParseUint(fields[1], 2, 16)
+			obj.Code = append(obj.Code, Word{Value: int(word)})
+		case key == "a" && len(fields) >= 3:
+			var word Word
+			word, err = parseRelocated(fields[1:])
+			obj.Code = append(obj.Code, word)
+		default:
+			err = fmt.Errorf("unknown entry %q", strings.TrimSpace(line))
+		}
+		if err != nil {
+			return Object{}, fmt.Errorf("line %d: %w", atLine, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Object{}, err
+	}
+	if !header {
+		return Object{}, fmt.Errorf("not a hack object, it is empty")
+	}
+	return obj, nil
+}
+
+// value followed by terms like +@, +LOOP or -x
+func parseRelocated(fields []string) (Word, error) {
+	value, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return Word{}, fmt.Errorf("malformed value %q", fields[0])
+	}
+	word := Word{Value: value}
+	for _, field := range fields[1:] {
+		if len(field) < 2 || (field[0] != '+' && field[0] != '-') {
+			return Word{}, fmt.Errorf("malformed relocation %q, expected +symbol or -symbol", field)
+		}
+		reloc := Reloc{Sign: 1, Symbol: field[1:]}
+		if field[0] == '-' {
+			reloc.Sign = -1
+		}
+		if reloc.Symbol == "@" {
+			reloc.Symbol = ""
+		}
+		word.Relocs = append(word.Relocs, reloc)
+	}
+	return word, nil
+}
@@ -0,0 +1,69 @@
+package object
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteRead(t *testing.T) {
+	obj := Object{
+		Name: "My Main.asm",
+		Code: []Word{
+			{Value: 0b1110101010000111},
+			{Value: 4, Relocs: []Reloc{{Sign: 1}}},
+			{Value: -1, Relocs: []Reloc{{Sign: 1, Symbol: "Sys.init"}, {Sign: -1, Symbol: "i"}}},
+		},
+		Exports: map[string]int{"Main.main": 0, "LOOP": 2},
+		Imports: []string{"Sys.init"},
+		Externs: []string{"i"},
+	}
+	expected := `hackobj 1
+module My Main.asm
+export Main.main 0
+export LOOP 2
+import Sys.init
+extern i
+w 1110101010000111
+a 4 +@
+a -1 +Sys.init -i
+`
+
+	var buf bytes.Buffer
+	if err := Write(&buf, obj); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != expected {
+		t.Fatalf("expected=%q, but got=%q", expected, buf.String())
+	}
+
+	got, err := Read(strings.NewReader("// written by hand\n" + buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, obj) {
+		t.Fatalf("expected=%+v, but got=%+v", obj, got)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedErr string
+	}{
+		{"", "not a hack object, it is empty"},
+		{"hackobj 2\n", "line 1: not a hack object, expected hackobj 1"},
+		{"hackobj 1\nw 12\n", `line 2: strconv.ParseUint: parsing "12"`},
+		{"hackobj 1\n\na 4 @\n", `line 3: malformed relocation "@"`},
+		{"hackobj 1\na x +@\n", `line 2: malformed value "x"`},
+		{"hackobj 1\nexport LOOP\n", `line 2: unknown entry "export LOOP"`},
+	}
+
+	for i, tt := range tests {
+		_, err := Read(strings.NewReader(tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+			t.Fatalf("tests[%d]: expected error %q, but got=%v", i, tt.expectedErr, err)
+		}
+	}
+}
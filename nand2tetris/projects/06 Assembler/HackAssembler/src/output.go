@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// stands for standard input as source and for standard output as output file
+const stdio = "-"
+
+// name of program read from standard input, used in messages and output file names
+const stdinName = "stdin"
+
+// returns path of file with the same name as source at filePath, but with
+// extension ext, eg: example/path/my.prog.asm -> example/path/my.prog.hack.
+// File is in directory of source, or in outdir if it is not empty.
+func OutputPath(filePath, ext, outdir string) string {
+	directory, fileName := filepath.Split(filePath)
+	if filePath == stdio {
+		directory, fileName = "", stdinName
+	}
+	if outdir != "" {
+		directory = outdir
+	}
+	return filepath.Join(directory, strings.TrimSuffix(fileName, filepath.Ext(fileName))+ext)
+}
+
+// returns path of machine code file for source at filePath, output is value of
+// -o flag. Program read from standard input is written to standard output,
+// unless -o or -outdir says otherwise.
+func codePath(filePath, ext, output, outdir string) string {
+	switch {
+	case output != "":
+		return output
+	case filePath == stdio && outdir == "":
+		return stdio
+	}
+	return OutputPath(filePath, ext, outdir)
+}
+
+// returns paths of files written for source at filePath as flags say,
+// machine code file has extension ext.
+func outputPaths(filePath, ext string) []string {
+	paths := []string{codePath(filePath, ext, *output, *outdir)}
+	if *listing {
+		if lstPath, err := listingPath(filePath, *output, *outdir); err == nil {
+			paths = append(paths, lstPath)
+		}
+	}
+	if *symbols != "" {
+		paths = append(paths, symbolsPath(filePath, *symbols, *outdir))
+	}
+	return paths
+}
+
+// returns path of listing file, it is next to machine code file given by -o,
+// otherwise it is named after source.
+func listingPath(filePath, output, outdir string) (string, error) {
+	switch {
+	case output != "" && output != stdio:
+		return strings.TrimSuffix(output, filepath.Ext(output)) + ".lst", nil
+	case filePath == stdio && outdir == "":
+		return "", fmt.Errorf("listing of standard input needs -o file or -outdir")
+	}
+	return OutputPath(filePath, ".lst", outdir), nil
+}
+
+// returns path of symbol table file, symbols is value of -symbols flag, it is
+// either the path or an extension like .json, which names the file after source.
+func symbolsPath(filePath, symbols, outdir string) string {
+	if isExtension(symbols) {
+		return OutputPath(filePath, symbols, outdir)
+	}
+	return symbols
+}
+
+// true if s is a file extension alone, eg: .json
+func isExtension(s string) bool {
+	return strings.HasPrefix(s, ".") && filepath.Ext(s) == s
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputPaths(t *testing.T) {
+	tests := []struct {
+		filePath, output, outdir string
+		expectedCode             string
+		expectedListing          string
+	}{
+		{"dir/Prog.asm", "", "", "dir/Prog.hack", "dir/Prog.lst"},
+		{"dir/my.prog.asm", "", "", "dir/my.prog.hack", "dir/my.prog.lst"},
+		{"dir/Prog", "", "", "dir/Prog.hack", "dir/Prog.lst"},
+		{"dir/Prog.asm", "", "build", "build/Prog.hack", "build/Prog.lst"},
+		{"dir/Prog.asm", "out/a.bin", "", "out/a.bin", "out/a.lst"},
+		{"dir/Prog.asm", "-", "", "-", "dir/Prog.lst"},
+		{"-", "", "", "-", ""},
+		{"-", "", "build", "build/stdin.hack", "build/stdin.lst"},
+		{"-", "a.hack", "", "a.hack", "a.lst"},
+	}
+
+	for i, tt := range tests {
+		code := codePath(tt.filePath, ".hack", tt.output, tt.outdir)
+		if filepath.ToSlash(code) != tt.expectedCode {
+			t.Fatalf("tests[%d]: expected code path=%v, but got=%v", i, tt.expectedCode, code)
+		}
+
+		listing, err := listingPath(tt.filePath, tt.output, tt.outdir)
+		if tt.expectedListing == "" {
+			if err == nil {
+				t.Fatalf("tests[%d]: expected error for listing path, but got=%v", i, listing)
+			}
+			continue
+		}
+		if err != nil || filepath.ToSlash(listing) != tt.expectedListing {
+			t.Fatalf("tests[%d]: expected listing path=%v, but got=%v, %v", i, tt.expectedListing, listing, err)
+		}
+	}
+}
+
+func TestSymbolsPath(t *testing.T) {
+	tests := []struct {
+		filePath, symbols, outdir string
+		expected                  string
+	}{
+		{"dir/Prog.asm", ".json", "", "dir/Prog.json"},
+		{"dir/Prog.asm", ".sym", "build", "build/Prog.sym"},
+		{"dir/Prog.asm", "out/prog.json", "build", "out/prog.json"},
+		{"-", ".sym", "", "stdin.sym"},
+	}
+
+	for i, tt := range tests {
+		path := symbolsPath(tt.filePath, tt.symbols, tt.outdir)
+		if filepath.ToSlash(path) != tt.expected {
+			t.Fatalf("tests[%d]: expected=%v, but got=%v", i, tt.expected, path)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// fields of messages of profile.proto, the format of go tool pprof
+const (
+	PROFILE_SAMPLE_TYPE  = 1
+	PROFILE_SAMPLE       = 2
+	PROFILE_LOCATION     = 4
+	PROFILE_FUNCTION     = 5
+	PROFILE_STRING_TABLE = 6
+	PROFILE_PERIOD_TYPE  = 11
+	PROFILE_PERIOD       = 12
+
+	VALUE_TYPE_TYPE = 1
+	VALUE_TYPE_UNIT = 2
+
+	SAMPLE_LOCATION_ID = 1
+	SAMPLE_VALUE       = 2
+
+	LOCATION_ID      = 1
+	LOCATION_ADDRESS = 3
+	LOCATION_LINE    = 4
+
+	LINE_FUNCTION_ID = 1
+	LINE_LINE        = 2
+
+	FUNCTION_ID          = 1
+	FUNCTION_NAME        = 2
+	FUNCTION_SYSTEM_NAME = 3
+	FUNCTION_FILENAME    = 4
+	FUNCTION_START_LINE  = 5
+)
+
+// WritePprof writes counts as gzipped profile of go tool pprof. Every ROM
+// address executed is a location, in its label, which is inlined in its VM
+// function, so pprof shows both, and at its source line:
+//
+//	go tool pprof -top -lines prog.pb.gz
+func (p *Profiler) WritePprof(w io.Writer) error {
+	labels, functions := p.regions()
+
+	strs := []string{""}
+	strIndex := map[string]int64{"": 0}
+	str := func(s string) int64 {
+		if i, ok := strIndex[s]; ok {
+			return i
+		}
+		strIndex[s] = int64(len(strs))
+		strs = append(strs, s)
+		return strIndex[s]
+	}
+
+	var profile, msg protobuf
+	valueType := func(tag int, kind, unit string) {
+		msg.Reset()
+		msg.int64Field(VALUE_TYPE_TYPE, str(kind))
+		msg.int64Field(VALUE_TYPE_UNIT, str(unit))
+		profile.message(tag, &msg)
+	}
+	valueType(PROFILE_SAMPLE_TYPE, "instructions", "count")
+	valueType(PROFILE_PERIOD_TYPE, "instructions", "count")
+	profile.int64Field(PROFILE_PERIOD, 1)
+
+	// key: name of label or VM function, value: id of pprof function
+	functionIDs := map[string]uint64{}
+	functionID := func(name string) uint64 {
+		if id, ok := functionIDs[name]; ok {
+			return id
+		}
+		id := uint64(len(functionIDs) + 1)
+		functionIDs[name] = id
+
+		file, line := p.Program.Name, 0
+		if site, ok := p.Program.Symbols.DefinedAt(name); ok {
+			file, line = site.File, site.Line
+		}
+		msg.Reset()
+		msg.uint64Field(FUNCTION_ID, id)
+		msg.int64Field(FUNCTION_NAME, str(name))
+		msg.int64Field(FUNCTION_SYSTEM_NAME, str(name))
+		msg.int64Field(FUNCTION_FILENAME, str(file))
+		msg.int64Field(FUNCTION_START_LINE, int64(line))
+		profile.message(PROFILE_FUNCTION, &msg)
+		return id
+	}
+
+	var line protobuf
+	for address, count := range p.Counts {
+		if count == 0 {
+			continue
+		}
+		id := uint64(address + 1)
+		msg.Reset()
+		msg.packed(SAMPLE_LOCATION_ID, []uint64{id})
+		msg.packed(SAMPLE_VALUE, []uint64{count})
+		profile.message(PROFILE_SAMPLE, &msg)
+
+		sourceLine := 0
+		if address < len(p.Program.Instrs) {
+			sourceLine = p.Program.Instrs[address].AtLine
+		}
+		// first line is the innermost frame
+		frames := []string{labels[address]}
+		if functions[address] != labels[address] {
+			frames = append(frames, functions[address])
+		}
+		ids := []uint64{}
+		for _, frame := range frames {
+			ids = append(ids, functionID(frame))
+		}
+
+		msg.Reset()
+		msg.uint64Field(LOCATION_ID, id)
+		msg.uint64Field(LOCATION_ADDRESS, uint64(address))
+		for _, fid := range ids {
+			line.Reset()
+			line.uint64Field(LINE_FUNCTION_ID, fid)
+			line.int64Field(LINE_LINE, int64(sourceLine))
+			msg.message(LOCATION_LINE, &line)
+		}
+		profile.message(PROFILE_LOCATION, &msg)
+	}
+
+	for _, s := range strs {
+		profile.stringField(PROFILE_STRING_TABLE, s)
+	}
+
+	gw := gzip.NewWriter(w)
+	if _, err := gw.Write(profile.Bytes()); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// encoder of protocol buffers, enough for profile.proto
+type protobuf struct {
+	bytes.Buffer
+}
+
+func (b *protobuf) varint(x uint64) {
+	for x >= 0x80 {
+		b.WriteByte(byte(x) | 0x80)
+		x >>= 7
+	}
+	b.WriteByte(byte(x))
+}
+
+func (b *protobuf) uint64Field(tag int, x uint64) {
+	if x == 0 {
+		return
+	}
+	b.varint(uint64(tag)<<3 | 0) // varint wire type
+	b.varint(x)
+}
+
+func (b *protobuf) int64Field(tag int, x int64) {
+	b.uint64Field(tag, uint64(x))
+}
+
+// repeated numbers
+func (b *protobuf) packed(tag int, xs []uint64) {
+	var values protobuf
+	for _, x := range xs {
+		values.varint(x)
+	}
+	b.bytesField(tag, values.Bytes())
+}
+
+// written even if empty, as in string table
+func (b *protobuf) stringField(tag int, s string) {
+	b.bytesField(tag, []byte(s))
+}
+
+func (b *protobuf) message(tag int, m *protobuf) {
+	b.bytesField(tag, m.Bytes())
+}
+
+func (b *protobuf) bytesField(tag int, data []byte) {
+	b.varint(uint64(tag)<<3 | 2) // length delimited wire type
+	b.varint(uint64(len(data)))
+	b.Write(data)
+}
@@ -0,0 +1,190 @@
+// Package profiler counts instructions a program executes at every ROM
+// address and reports where its cycles go, per label, per VM function and
+// per source line, as text or as a profile for go tool pprof.
+package profiler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ishwar00/HackAssembler/assembler"
+	"github.com/ishwar00/HackAssembler/emulator"
+)
+
+// name of instructions before the first label of program
+const START = "(start)"
+
+// Profiler counts instructions executed by Run, Hack executes one
+// instruction per cycle, so counts are cycles too.
+type Profiler struct {
+	Program assembler.Program
+	Counts  [emulator.ROM_SIZE]uint64 // key: ROM address
+}
+
+// Entry is cycles spent in a label, function or source line.
+type Entry struct {
+	Name   string
+	Source string // source of line, only for lines
+	Count  uint64
+}
+
+func New(program assembler.Program) *Profiler {
+	return &Profiler{Program: program}
+}
+
+// Run executes instructions like emulator.Computer.Run, counting them.
+func (p *Profiler) Run(c *emulator.Computer, maxCycles uint64) (cycles uint64, halted bool) {
+	for maxCycles == 0 || cycles < maxCycles {
+		if c.Halted() {
+			return cycles, true
+		}
+		p.Counts[c.PC&(emulator.ROM_SIZE-1)]++
+		c.Step()
+		cycles++
+	}
+	return cycles, c.Halted()
+}
+
+// Total returns number of instructions counted.
+func (p *Profiler) Total() uint64 {
+	total := uint64(0)
+	for _, count := range p.Counts {
+		total += count
+	}
+	return total
+}
+
+// Labels returns cycles spent from each label to the next one, eg: in a VM
+// function Foo.bar up to its first label and in its Foo.bar$ret.0 block, most
+// expensive first.
+func (p *Profiler) Labels() []Entry {
+	labels, _ := p.regions()
+	return p.aggregate(func(address int) string { return labels[address] })
+}
+
+// Functions returns cycles spent in each VM function, with its labels
+// Foo.bar$... and labels of comparisons, which are not functions, most
+// expensive first. A program which is not translated from VM code has no
+// functions, its labels are returned.
+func (p *Profiler) Functions() []Entry {
+	_, functions := p.regions()
+	return p.aggregate(func(address int) string { return functions[address] })
+}
+
+// Lines returns cycles spent in each source line, most expensive first.
+func (p *Profiler) Lines() []Entry {
+	entries := p.aggregate(func(address int) string {
+		if address < len(p.Program.Instrs) {
+			instrInfo := p.Program.Instrs[address]
+			return fmt.Sprintf("%v:%d", filepath.Base(instrInfo.InFile), instrInfo.AtLine)
+		}
+		return fmt.Sprintf("ROM[%d]", address)
+	})
+	sources := map[string]string{}
+	for address, instrInfo := range p.Program.Instrs {
+		name := fmt.Sprintf("%v:%d", filepath.Base(instrInfo.InFile), instrInfo.AtLine)
+		sources[name] = p.sourceText(address)
+	}
+	for i := range entries {
+		entries[i].Source = sources[entries[i].Name]
+	}
+	return entries
+}
+
+// sums counts of addresses by name, entries are ordered by count and name.
+func (p *Profiler) aggregate(nameOf func(address int) string) []Entry {
+	counts := map[string]uint64{}
+	for address, count := range p.Counts {
+		if count != 0 {
+			counts[nameOf(address)] += count
+		}
+	}
+	entries := []Entry{}
+	for name, count := range counts {
+		entries = append(entries, Entry{Name: name, Count: count})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count == entries[j].Count {
+			return entries[i].Name < entries[j].Name
+		}
+		return entries[i].Count > entries[j].Count
+	})
+	return entries
+}
+
+// label and VM function of every ROM address of program.
+func (p *Profiler) regions() (labels, functions []string) {
+	labelsAt := map[int][]string{}
+	hasFunctions := false
+	for _, label := range p.Program.Symbols.Symbols(assembler.LABEL_SYMBOL) {
+		address := p.Program.Symbols.GetAddress(label)
+		labelsAt[address] = append(labelsAt[address], label)
+		hasFunctions = hasFunctions || strings.Contains(label, "$")
+	}
+
+	labels = make([]string, emulator.ROM_SIZE)
+	functions = make([]string, emulator.ROM_SIZE)
+	label, function := START, START
+	for address := range labels {
+		for _, l := range labelsAt[address] {
+			label = l
+			switch name, _, isLocal := strings.Cut(l, "$"); {
+			case !hasFunctions:
+				function = l
+			case isLocal:
+				function = name
+			case strings.Contains(l, "."): // Foo.bar, labels of comparisons have no dot
+				function = l
+			}
+		}
+		labels[address], functions[address] = label, function
+	}
+	return labels, functions
+}
+
+// source line of instruction at ROM address as written.
+func (p *Profiler) sourceText(address int) string {
+	instrInfo := p.Program.Instrs[address]
+	source := p.Program.Source[instrInfo.InFile]
+	if at := instrInfo.AtLine - 1; instrInfo.Macro == "" && at >= 0 && at < len(source) {
+		return strings.TrimSpace(source[at])
+	}
+	return instrInfo.Instr
+}
+
+// WriteReport writes top entries of functions, labels and lines, with their
+// share of all cycles, top 0 writes all of them.
+func (p *Profiler) WriteReport(w io.Writer, top int) error {
+	bw := bufio.NewWriter(w)
+	total := p.Total()
+	fmt.Fprintf(bw, "// profile of %v, %d cycles\n", p.Program.Name, total)
+
+	sections := []struct {
+		title   string
+		entries []Entry
+	}{
+		{"functions", p.Functions()},
+		{"labels", p.Labels()},
+		{"lines", p.Lines()},
+	}
+	for _, section := range sections {
+		fmt.Fprintf(bw, "\n// %v\n", section.title)
+		fmt.Fprintf(bw, "%12s  %6s  %s\n", "cycles", "share", "name")
+		for i, entry := range section.entries {
+			if top > 0 && i == top {
+				break
+			}
+			share := 100 * float64(entry.Count) / float64(total)
+			fmt.Fprintf(bw, "%12d  %5.2f%%  %v", entry.Count, share, entry.Name)
+			if entry.Source != "" {
+				fmt.Fprintf(bw, "\t%v", entry.Source)
+			}
+			fmt.Fprintln(bw)
+		}
+	}
+	return bw.Flush()
+}
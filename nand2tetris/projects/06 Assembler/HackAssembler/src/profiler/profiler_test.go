@@ -0,0 +1,134 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ishwar00/HackAssembler/emulator"
+	"github.com/ishwar00/HackAssembler/files"
+)
+
+func run(t *testing.T, asmPath string, set map[int]uint16) *Profiler {
+	t.Helper()
+	program, err := files.ReadProgram(asmPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	computer := new(emulator.Computer)
+	if err := computer.Load(program.Words); err != nil {
+		t.Fatal(err)
+	}
+	for address, value := range set {
+		computer.RAM[address] = value
+	}
+	p := New(program)
+	if _, halted := p.Run(computer, 1000000); !halted {
+		t.Fatalf("%v: expected program to halt", asmPath)
+	}
+	return p
+}
+
+func TestProfile(t *testing.T) {
+	// 4 instructions before LOOP, 6 to test i = R0 for i = 0..3 and 8 to add
+	// R1 for i = 0..2, final loop at END is not counted
+	p := run(t, "../../../../04 Machine Language/mult/Mult.asm", map[int]uint16{0: 3, 1: 4})
+	if total := p.Total(); total != 52 {
+		t.Fatalf("expected=52 cycles, but got=%d", total)
+	}
+	expected := []Entry{{Name: "LOOP", Count: 48}, {Name: START, Count: 4}}
+	if got := p.Labels(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("labels: expected=%v, but got=%v", expected, got)
+	}
+	if got := p.Functions(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("functions: expected=%v, but got=%v", expected, got)
+	}
+	lines := p.Lines()
+	if first := (Entry{Name: "Mult.asm:18", Source: "@i", Count: 4}); lines[0] != first {
+		t.Fatalf("lines: expected=%v first, but got=%v", first, lines[0])
+	}
+
+	// labels of VM functions and their return addresses
+	p = run(t, "../../../../08 Virtual Machine 2 (control)/FunctionCalls/FibonacciElement/FibonacciElement.asm", nil)
+	functions := map[string]uint64{}
+	for _, entry := range p.Functions() {
+		functions[entry.Name] = entry.Count
+	}
+	labels := map[string]uint64{}
+	for _, entry := range p.Labels() {
+		labels[entry.Name] = entry.Count
+	}
+	if len(functions) != 3 || functions["Main.fibonacci"] == 0 || functions["Sys.init"] == 0 {
+		t.Fatalf("expected functions Main.fibonacci, Sys.init and %v, but got=%v", START, functions)
+	}
+	if labels["Main.fibonacci$ret.1"] == 0 || labels["Main.fibonacci$IF_TRUE"] == 0 {
+		t.Fatalf("expected cycles in Main.fibonacci$ret.1 and Main.fibonacci$IF_TRUE, but got=%v", labels)
+	}
+
+	var report bytes.Buffer
+	if err := p.WriteReport(&report, 2); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(report.String(), "        1440  94.86%  Main.fibonacci\n") {
+		t.Fatalf("expected report to show Main.fibonacci, but got=\n%v", report.String())
+	}
+}
+
+func TestWritePprof(t *testing.T) {
+	p := run(t, "../../../../04 Machine Language/mult/Mult.asm", map[int]uint16{0: 3, 1: 4})
+	var out bytes.Buffer
+	if err := p.WritePprof(&out); err != nil {
+		t.Fatal(err)
+	}
+	gr, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile, err := io.ReadAll(gr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sum of values of samples is number of cycles
+	fields := decode(t, profile)
+	total, strs := uint64(0), []string{}
+	for _, sample := range fields[PROFILE_SAMPLE] {
+		values := decode(t, sample)[SAMPLE_VALUE][0]
+		value, _ := binary.Uvarint(values)
+		total += value
+	}
+	for _, s := range fields[PROFILE_STRING_TABLE] {
+		strs = append(strs, string(s))
+	}
+	if total != 52 || len(fields[PROFILE_LOCATION]) != len(fields[PROFILE_SAMPLE]) {
+		t.Fatalf("expected samples of 52 cycles, one per location, but got=%d cycles", total)
+	}
+	if expected := []string{"", "instructions", "count", START}; !reflect.DeepEqual(strs[:4], expected) || strs[len(strs)-1] != "LOOP" {
+		t.Fatalf("unexpected string table %q", strs)
+	}
+}
+
+// decodes length delimited fields of a protocol buffer message, other fields
+// are skipped. key: field number, value: contents of fields
+func decode(t *testing.T, msg []byte) map[int][][]byte {
+	t.Helper()
+	fields := map[int][][]byte{}
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+		msg = msg[n:]
+		value, n := binary.Uvarint(msg)
+		if n <= 0 {
+			t.Fatalf("malformed message")
+		}
+		msg = msg[n:]
+		if key&7 == 2 {
+			fields[int(key>>3)] = append(fields[int(key>>3)], msg[:value])
+			msg = msg[value:]
+		}
+	}
+	return fields
+}
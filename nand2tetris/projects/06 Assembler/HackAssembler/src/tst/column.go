@@ -0,0 +1,80 @@
+package tst
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// column of output-list, RAM[0]%D2.6.2 prints RAM[0] in decimal, right
+// aligned in 6 characters, with 2 spaces on the left and 2 on the right.
+type column struct {
+	variable string
+	format   byte // D decimal, X hexadecimal, B binary or S string
+	left     int
+	width    int
+	right    int
+}
+
+// parses VARIABLE%Fl.w.r, without format a column is %B1.16.1
+func parseColumn(spec string) (column, error) {
+	variable, format, ok := strings.Cut(spec, "%")
+	if !ok {
+		return column{variable, 'B', 1, 16, 1}, nil
+	}
+	malformed := fmt.Errorf("malformed output column %v, expected VARIABLE%%Fl.w.r, eg: RAM[0]%%D2.6.2", spec)
+	if variable == "" || len(format) < 1 || !strings.ContainsRune("DXBS", rune(format[0])) {
+		return column{}, malformed
+	}
+	widths := strings.Split(format[1:], ".")
+	if len(widths) != 3 {
+		return column{}, malformed
+	}
+	numbers := [3]int{}
+	for i, w := range widths {
+		n, err := strconv.Atoi(w)
+		if err != nil || n < 0 {
+			return column{}, malformed
+		}
+		numbers[i] = n
+	}
+	if numbers[1] == 0 {
+		return column{}, malformed
+	}
+	return column{variable, format[0], numbers[0], numbers[1], numbers[2]}, nil
+}
+
+// name of variable centered in the column.
+func (c column) header() string {
+	total := c.left + c.width + c.right
+	name := c.variable
+	if len(name) > total {
+		name = name[:total]
+	}
+	left := (total - len(name)) / 2
+	return strings.Repeat(" ", left) + name + strings.Repeat(" ", total-left-len(name))
+}
+
+// value formatted in the column, binary and hexadecimal keep their
+// rightmost digits if width is smaller than 16 or 4.
+func (c column) cell(value int64) string {
+	var text string
+	switch c.format {
+	case 'D':
+		text = fmt.Sprintf("%*d", c.width, value)
+	case 'S':
+		text = fmt.Sprintf("%-*d", c.width, value)
+	case 'X':
+		text = fmt.Sprintf("%*s", c.width, rightmost(fmt.Sprintf("%04X", uint16(value)), c.width))
+	case 'B':
+		text = fmt.Sprintf("%*s", c.width, rightmost(fmt.Sprintf("%016b", uint16(value)), c.width))
+	}
+	return strings.Repeat(" ", c.left) + text + strings.Repeat(" ", c.right)
+}
+
+func rightmost(s string, n int) string {
+	if len(s) > n {
+		return s[len(s)-n:]
+	}
+	return s
+}
@@ -0,0 +1,450 @@
+package tst
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ishwar00/HackAssembler/assembler"
+	"github.com/ishwar00/HackAssembler/emulator"
+	"github.com/ishwar00/HackAssembler/formats"
+)
+
+type Options struct {
+	// echo command writes its text here, nil discards it
+	Echo io.Writer
+
+	// repeat without count runs until program halts or for MaxCycles
+	// instructions, a while loop fails after MaxCycles, 0 means no limit
+	MaxCycles uint64
+
+	// directory .out files are written to, "" means directory of script
+	OutputDir string
+}
+
+// ComparisonError is returned when a line written to .out file differs from
+// the same line of .cmp file, script stops at the first difference.
+type ComparisonError struct {
+	Script   string
+	Line     int // line of .out and .cmp files, starting at 1
+	Expected string
+	Got      string
+}
+
+func (e *ComparisonError) Error() string {
+	return fmt.Sprintf("%v: comparison failure at line %d: expected=%q, but got=%q", e.Script, e.Line, e.Expected, e.Got)
+}
+
+// RunFile runs test script at path, files named by script are relative to
+// directory of script.
+func RunFile(path string, opts Options) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return Run(src, path, filepath.Dir(path), opts)
+}
+
+// Run runs test script read from src, name is used in errors and files named
+// by script are relative to dir.
+func Run(src io.Reader, name, dir string, opts Options) (err error) {
+	script, err := io.ReadAll(src)
+	if err != nil {
+		return err
+	}
+	commands, err := parse(string(script))
+	if err != nil {
+		return fmt.Errorf("%v:%v", name, err)
+	}
+
+	r := &runner{opts: opts, name: name, dir: dir, computer: new(emulator.Computer)}
+	defer func() {
+		if closeErr := r.closeOutput(); err == nil {
+			err = closeErr
+		}
+	}()
+	return r.run(commands)
+}
+
+type runner struct {
+	opts      Options
+	name, dir string
+	computer  *emulator.Computer
+
+	columns  []column
+	outFile  *os.File
+	out      *bufio.Writer
+	cmp      []string // lines of .cmp file, nil without compare-to
+	outLines int      // lines written to .out file
+}
+
+// error of command located in script, errors of commands in body of repeat
+// and while are located at the command in body.
+type scriptError struct {
+	msg string
+}
+
+func (e *scriptError) Error() string {
+	return e.msg
+}
+
+func (r *runner) run(commands []command) error {
+	for _, cmd := range commands {
+		if err := r.exec(cmd); err != nil {
+			switch err.(type) {
+			case *ComparisonError, *scriptError:
+				return err
+			}
+			return &scriptError{fmt.Sprintf("%v:%d: %v", r.name, cmd.line, err)}
+		}
+	}
+	return nil
+}
+
+func (r *runner) exec(cmd command) error {
+	if (cmd.name == "output" || cmd.name == "ticktock") && len(cmd.args) != 0 {
+		return fmt.Errorf("%v expects no arguments, but got %v", cmd.name, strings.Join(cmd.args, " "))
+	}
+	switch cmd.name {
+	case "load":
+		if len(cmd.args) != 1 {
+			return fmt.Errorf("load expects a program file")
+		}
+		return r.load(filepath.Join(r.dir, cmd.args[0]))
+
+	case "output-file":
+		if len(cmd.args) != 1 {
+			return fmt.Errorf("output-file expects a file")
+		}
+		dir := r.opts.OutputDir
+		if dir == "" {
+			dir = r.dir
+		}
+		if err := r.closeOutput(); err != nil {
+			return err
+		}
+		file, err := os.Create(filepath.Join(dir, cmd.args[0]))
+		if err != nil {
+			return err
+		}
+		r.outFile, r.out, r.outLines = file, bufio.NewWriter(file), 0
+
+	case "compare-to":
+		if len(cmd.args) != 1 {
+			return fmt.Errorf("compare-to expects a file")
+		}
+		content, err := os.ReadFile(filepath.Join(r.dir, cmd.args[0]))
+		if err != nil {
+			return err
+		}
+		r.cmp = strings.Split(strings.ReplaceAll(string(content), "\r", ""), "\n")
+
+	case "output-list":
+		columns := []column{}
+		for _, arg := range cmd.args {
+			col, err := parseColumn(arg)
+			if err != nil {
+				return err
+			}
+			if _, err := r.get(col.variable); err != nil {
+				return err
+			}
+			columns = append(columns, col)
+		}
+		r.columns = columns
+		line := "|"
+		for _, col := range columns {
+			line += col.header() + "|"
+		}
+		return r.output(line)
+
+	case "output":
+		line := "|"
+		for _, col := range r.columns {
+			value, err := r.get(col.variable)
+			if err != nil {
+				return err
+			}
+			line += col.cell(value) + "|"
+		}
+		return r.output(line)
+
+	case "set":
+		if len(cmd.args) != 2 {
+			return fmt.Errorf("set expects a variable and a value")
+		}
+		value, err := parseValue(cmd.args[1])
+		if err != nil {
+			return err
+		}
+		return r.set(cmd.args[0], uint16(value))
+
+	case "ticktock":
+		r.computer.Step()
+
+	case "repeat":
+		return r.repeat(cmd)
+
+	case "while":
+		return r.while(cmd)
+
+	case "echo":
+		if r.opts.Echo != nil {
+			fmt.Fprintln(r.opts.Echo, strings.Join(cmd.args, " "))
+		}
+
+	case "clear-echo", "breakpoint", "clear-breakpoints":
+		// only meaningful in the graphical emulator
+
+	default:
+		return fmt.Errorf("unknown command %v", cmd.name)
+	}
+	return nil
+}
+
+// loads program into ROM, .asm is assembled, others are read in the format
+// known from extension.
+func (r *runner) load(path string) error {
+	ext := filepath.Ext(path)
+	if strings.EqualFold(ext, ".hdl") {
+		return fmt.Errorf("%v: hardware simulator scripts are not supported", filepath.Base(path))
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var words []uint16
+	if strings.EqualFold(ext, ".asm") {
+		program, err := assembler.Assemble(file, path)
+		if err != nil {
+			return err
+		}
+		words = program.Words
+	} else {
+		format, ok := formats.ByExtension(ext)
+		if !ok {
+			return fmt.Errorf("%v: unknown program format", filepath.Base(path))
+		}
+		if words, err = format.Read(file); err != nil {
+			return fmt.Errorf("%v: %v", filepath.Base(path), err)
+		}
+	}
+	return r.computer.Load(words)
+}
+
+// repeat N { ... } runs body N times, without N it runs until program halts.
+func (r *runner) repeat(cmd command) error {
+	if len(cmd.args) > 1 {
+		return fmt.Errorf("repeat expects a count")
+	}
+	if len(cmd.args) == 1 {
+		count, err := strconv.Atoi(cmd.args[0])
+		if err != nil || count < 0 {
+			return fmt.Errorf("repeat count %v is not a number", cmd.args[0])
+		}
+		for i := 0; i < count; i++ {
+			if err := r.run(cmd.body); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	start := r.computer.Cycles
+	for !r.computer.Halted() && (r.opts.MaxCycles == 0 || r.computer.Cycles-start < r.opts.MaxCycles) {
+		cycles := r.computer.Cycles
+		if err := r.run(cmd.body); err != nil {
+			return err
+		}
+		if r.computer.Cycles == cycles { // body without ticktock would repeat forever
+			break
+		}
+	}
+	return nil
+}
+
+// while VARIABLE OP VALUE { ... } runs body as long as condition holds, OP is
+// one of = <> < > <= >=
+func (r *runner) while(cmd command) error {
+	condition := strings.Join(cmd.args, "")
+	at := strings.IndexAny(condition, "<>=")
+	if at <= 0 {
+		return fmt.Errorf("malformed condition %q, expected VARIABLE OP VALUE", condition)
+	}
+	op := condition[at : at+1]
+	if rest := condition[at+1:]; strings.HasPrefix(rest, "=") || op == "<" && strings.HasPrefix(rest, ">") {
+		op = condition[at : at+2]
+	}
+	variable := condition[:at]
+	value, err := parseValue(condition[at+len(op):])
+	if err != nil {
+		return err
+	}
+	if variable != "time" {
+		value = int64(int16(value))
+	}
+
+	start := r.computer.Cycles
+	for {
+		current, err := r.get(variable)
+		if err != nil {
+			return err
+		}
+		holds := map[string]bool{
+			"=": current == value, "<>": current != value,
+			"<": current < value, ">": current > value,
+			"<=": current <= value, ">=": current >= value,
+		}[op]
+		if !holds {
+			return nil
+		}
+		if r.opts.MaxCycles != 0 && r.computer.Cycles-start >= r.opts.MaxCycles {
+			return fmt.Errorf("while %v did not end after %d cycles", condition, r.opts.MaxCycles)
+		}
+		if err := r.run(cmd.body); err != nil {
+			return err
+		}
+	}
+}
+
+// writes line to .out file and compares it with the same line of .cmp file.
+func (r *runner) output(line string) error {
+	if r.out == nil {
+		return fmt.Errorf("output-file is not set")
+	}
+	fmt.Fprintln(r.out, line)
+	r.outLines++
+	if r.cmp == nil {
+		return nil
+	}
+	expected := ""
+	if r.outLines <= len(r.cmp) {
+		expected = r.cmp[r.outLines-1]
+	}
+	if !matches(expected, line) {
+		return &ComparisonError{r.name, r.outLines, expected, line}
+	}
+	return nil
+}
+
+func (r *runner) closeOutput() error {
+	if r.outFile == nil {
+		return nil
+	}
+	err := r.out.Flush()
+	if closeErr := r.outFile.Close(); err == nil {
+		err = closeErr
+	}
+	r.outFile, r.out = nil, nil
+	return err
+}
+
+// value of variable, registers and memory are signed 16 bit numbers.
+func (r *runner) get(variable string) (int64, error) {
+	c := r.computer
+	switch variable {
+	case "A", "ARegister":
+		return int64(int16(c.A)), nil
+	case "D", "DRegister":
+		return int64(int16(c.D)), nil
+	case "PC":
+		return int64(c.PC), nil
+	case "time":
+		return int64(c.Cycles), nil
+	}
+	if address, ok, err := memoryAddress(variable, "RAM"); ok {
+		if err != nil {
+			return 0, err
+		}
+		return int64(int16(c.RAM[address])), nil
+	}
+	if address, ok, err := memoryAddress(variable, "ROM"); ok {
+		if err != nil {
+			return 0, err
+		}
+		return int64(int16(c.ROM[address])), nil
+	}
+	return 0, fmt.Errorf("unknown variable %v", variable)
+}
+
+func (r *runner) set(variable string, value uint16) error {
+	c := r.computer
+	switch variable {
+	case "A", "ARegister":
+		c.A = value
+	case "D", "DRegister":
+		c.D = value
+	case "PC":
+		c.PC = value
+	case "time":
+		return fmt.Errorf("time can not be set")
+	default:
+		if address, ok, err := memoryAddress(variable, "RAM"); ok {
+			if err != nil {
+				return err
+			}
+			c.RAM[address] = value
+			return nil
+		}
+		if address, ok, err := memoryAddress(variable, "ROM"); ok {
+			if err != nil {
+				return err
+			}
+			c.ROM[address] = value
+			return nil
+		}
+		return fmt.Errorf("unknown variable %v", variable)
+	}
+	return nil
+}
+
+// RAM[16384] -> 16384, ok is false if variable is not an element of memory.
+func memoryAddress(variable, memory string) (address int, ok bool, err error) {
+	if !strings.HasPrefix(variable, memory+"[") || !strings.HasSuffix(variable, "]") {
+		return 0, false, nil
+	}
+	index := variable[len(memory)+1 : len(variable)-1]
+	address, err = strconv.Atoi(index)
+	if err != nil || address < 0 || address >= emulator.RAM_SIZE {
+		return 0, true, fmt.Errorf("%v: %v is not an address of %v", variable, index, memory)
+	}
+	return address, true, nil
+}
+
+// parses value of set and while, decimal by default or in format given by
+// prefix %D, %X or %B, eg: -1, %XFFFF, %B1111111111111111
+func parseValue(s string) (int64, error) {
+	base, digits := 10, s
+	if len(s) >= 2 && s[0] == '%' {
+		bases := map[byte]int{'D': 10, 'X': 16, 'B': 2}
+		if base = bases[s[1]]; base == 0 {
+			return 0, fmt.Errorf("unknown format %v of value %v, expected %%D, %%X or %%B", s[:2], s)
+		}
+		digits = s[2:]
+	}
+	value, err := strconv.ParseInt(digits, base, 32)
+	if err != nil || value < -32768 || value > 65535 {
+		return 0, fmt.Errorf("%v is not a 16 bit value", s)
+	}
+	return value, nil
+}
+
+// line matches expected line of .cmp file, where * matches any character.
+func matches(expected, line string) bool {
+	if len(expected) != len(line) {
+		return false
+	}
+	for i := 0; i < len(line); i++ {
+		if expected[i] != '*' && expected[i] != line[i] {
+			return false
+		}
+	}
+	return true
+}
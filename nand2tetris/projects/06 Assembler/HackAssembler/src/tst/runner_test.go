@@ -0,0 +1,124 @@
+package tst
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// test scripts of projects 04, 07 and 08 pass with programs in the repository.
+func TestRunScripts(t *testing.T) {
+	tests := []string{
+		"../../../../04 Machine Language/mult/Mult.tst",
+		"../../../../04 Machine Language/fill/FillAutomatic.tst",
+		"../../../../07 Virtual Machine 1 (processing)/StackArithmetic/SimpleAdd/SimpleAdd.tst",
+		"../../../../07 Virtual Machine 1 (processing)/StackArithmetic/StackTest/StackTest.tst",
+		"../../../../07 Virtual Machine 1 (processing)/MemoryAccess/BasicTest/BasicTest.tst",
+		"../../../../07 Virtual Machine 1 (processing)/MemoryAccess/PointerTest/PointerTest.tst",
+		"../../../../07 Virtual Machine 1 (processing)/MemoryAccess/StaticTest/StaticTest.tst",
+		"../../../../08 Virtual Machine 2 (control)/ProgramFlow/BasicLoop/BasicLoop.tst",
+		"../../../../08 Virtual Machine 2 (control)/ProgramFlow/FibonacciSeries/FibonacciSeries.tst",
+		"../../../../08 Virtual Machine 2 (control)/FunctionCalls/SimpleFunction/SimpleFunction.tst",
+		"../../../../08 Virtual Machine 2 (control)/FunctionCalls/NestedCall/NestedCall.tst",
+		"../../../../08 Virtual Machine 2 (control)/FunctionCalls/FibonacciElement/FibonacciElement.tst",
+		"../../../../08 Virtual Machine 2 (control)/FunctionCalls/StaticsTest/StaticsTest.tst",
+	}
+
+	for _, tstPath := range tests {
+		outDir := t.TempDir()
+		if err := RunFile(tstPath, Options{OutputDir: outDir}); err != nil {
+			t.Fatal(err)
+		}
+		base := strings.TrimSuffix(filepath.Base(tstPath), ".tst")
+		got, err := os.ReadFile(filepath.Join(outDir, base+".out"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := os.ReadFile(strings.TrimSuffix(tstPath, ".tst") + ".cmp")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.TrimSpace(string(got)) != strings.TrimSpace(strings.ReplaceAll(string(expected), "\r", "")) {
+			t.Fatalf("%v: expected=%q, but got=%q", tstPath, expected, got)
+		}
+	}
+}
+
+func TestColumn(t *testing.T) {
+	tests := []struct {
+		spec   string
+		value  int64
+		header string
+		cell   string
+	}{
+		{"RAM[0]%D2.6.2", 42, "  RAM[0]  ", "      42  "},
+		{"RAM[0]%D1.6.1", -91, " RAM[0] ", "    -91 "},
+		{"RAM[16384]%D2.6.2", -1, "RAM[16384]", "      -1  "},
+		{"time%S1.4.1", 7, " time ", " 7    "},
+		{"A%X1.4.1", -1, "  A   ", " FFFF "},
+		{"D%B1.4.1", 6, "  D   ", " 0110 "},
+		{"PC", 5, "        PC        ", " 0000000000000101 "},
+	}
+
+	for i, tt := range tests {
+		col, err := parseColumn(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header := col.header(); header != tt.header {
+			t.Fatalf("tests[%d]: header: expected=%q, but got=%q", i, tt.header, header)
+		}
+		if cell := col.cell(tt.value); cell != tt.cell {
+			t.Fatalf("tests[%d]: cell: expected=%q, but got=%q", i, tt.cell, cell)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"Double.asm": "@R0\nD=M\nM=D+M\n(END)\n@END\n0;JMP\n",
+		"Double.cmp": "|  RAM[0]  |\n|      12  |\n",
+		"Wild.cmp":   "|  RAM[0]  |\n|      *2  |\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		script   string
+		expected string // error, "" if script passes
+	}{
+		{"load Double.asm, output-file Double.out, compare-to Double.cmp,\n" +
+			"output-list RAM[0]%D2.6.2;\nset RAM[0] 6;\nrepeat { ticktock; }\noutput;", ""},
+		{"load Double.asm, output-file Double.out, compare-to Wild.cmp,\n" +
+			"output-list RAM[0]%D2.6.2;\nset RAM[0] %X6;\nwhile PC < 3 { ticktock; }\noutput;", ""},
+		{"load Double.asm, output-file Double.out, compare-to Double.cmp,\n" +
+			"output-list RAM[0]%D2.6.2;\nset RAM[0] 7;\nrepeat 3 { ticktock; }\noutput;",
+			"comparison failure at line 2"},
+		{"load Double.asm,\n/* no output file */\noutput;", "test.tst:3: output-file is not set"},
+		{"load Double.hdl;", "test.tst:1: Double.hdl: hardware simulator scripts are not supported"},
+		{"load Double.asm;\nset RAM[32768] 1;", "test.tst:2: RAM[32768]: 32768 is not an address of RAM"},
+		{"set D 70000;", "test.tst:1: 70000 is not a 16 bit value"},
+		{"tick;", "test.tst:1: unknown command tick"},
+		{"output-list RAM[0]%Q1.2.3;", "test.tst:1: malformed output column RAM[0]%Q1.2.3"},
+		{"repeat 3 {\n  ticktock;\n", "test.tst:1: { of repeat is not closed with }"},
+		{"ticktock;\nticktock", "test.tst:2: ticktock is not ended with , ; or !"},
+		{"ticktock\noutput;", "test.tst:1: ticktock expects no arguments, but got output"},
+		{"repeat 2 {\n  set PC -1;\n  ticktock;\n  set PC 70000;\n}", "test.tst:4: 70000 is not a 16 bit value"},
+		{"echo \"unclosed;", "test.tst:1: string is not closed with \""},
+	}
+
+	for i, tt := range tests {
+		err := Run(strings.NewReader(tt.script), "test.tst", dir, Options{MaxCycles: 1000})
+		switch {
+		case tt.expected == "" && err != nil:
+			t.Fatalf("tests[%d]: expected script to pass, but got=%v", i, err)
+		case tt.expected != "" && (err == nil || !strings.Contains(err.Error(), tt.expected)):
+			t.Fatalf("tests[%d]: expected=%q, but got=%v", i, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Package tst runs test scripts of the CPU emulator of the course, the .tst
+// files of projects 04, 07 and 08. A script loads a program, sets registers
+// and memory, runs the program with ticktock and writes values of variables
+// to an .out file with output, every line written is compared with the same
+// line of a .cmp file.
+//
+//	load Mult.asm,
+//	output-file Mult.out,
+//	compare-to Mult.cmp,
+//	output-list RAM[0]%D2.6.2 RAM[1]%D2.6.2 RAM[2]%D2.6.2;
+//	set RAM[0] 6, set RAM[1] 7;
+//	repeat 210 {
+//	  ticktock;
+//	}
+//	output;
+//
+// Scripts of the hardware simulator, which load .hdl chips, are not supported.
+package tst
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	WORD_TOKEN   = iota // command, variable, value or path
+	STRING_TOKEN        // "quoted text", quotes are removed
+	LBRACE_TOKEN        // {
+	RBRACE_TOKEN        // }
+	END_TOKEN           // , ; or ! which end a command
+)
+
+type token struct {
+	kind int
+	text string
+	line int
+}
+
+// command of script, repeat and while have a body of commands.
+type command struct {
+	name string
+	args []string
+	body []command
+	line int
+}
+
+// splits script into tokens, comments are // to end of line and /* */.
+func tokenize(script string) ([]token, error) {
+	tokens := []token{}
+	line := 1
+	for i := 0; i < len(script); {
+		c := script[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(script[i:], "//"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			i += end
+		case strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%d: comment is not closed with */", line)
+			}
+			line += strings.Count(script[i:i+2+end], "\n")
+			i += end + 4
+		case c == '"':
+			end := strings.IndexAny(script[i+1:], "\"\n")
+			if end < 0 || script[i+1+end] != '"' {
+				return nil, fmt.Errorf("%d: string is not closed with \"", line)
+			}
+			tokens = append(tokens, token{STRING_TOKEN, script[i+1 : i+1+end], line})
+			i += end + 2
+		case c == '{':
+			tokens = append(tokens, token{LBRACE_TOKEN, "{", line})
+			i++
+		case c == '}':
+			tokens = append(tokens, token{RBRACE_TOKEN, "}", line})
+			i++
+		case c == ',' || c == ';' || c == '!':
+			tokens = append(tokens, token{END_TOKEN, string(c), line})
+			i++
+		default:
+			start := i
+			for i < len(script) && !strings.ContainsRune(" \t\r\n{},;!\"", rune(script[i])) &&
+				!strings.HasPrefix(script[i:], "//") && !strings.HasPrefix(script[i:], "/*") {
+				i++
+			}
+			tokens = append(tokens, token{WORD_TOKEN, script[start:i], line})
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parses script into commands.
+func parse(script string) ([]command, error) {
+	tokens, err := tokenize(script)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	commands, err := p.parseCommands()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("%d: unexpected }", p.tokens[p.pos].line)
+	}
+	return commands, nil
+}
+
+// parses commands until end of script or }, which is not consumed.
+func (p *parser) parseCommands() ([]command, error) {
+	commands := []command{}
+	for p.pos < len(p.tokens) {
+		tok := p.tokens[p.pos]
+		switch tok.kind {
+		case RBRACE_TOKEN:
+			return commands, nil
+		case END_TOKEN: // empty command
+			p.pos++
+			continue
+		case WORD_TOKEN:
+		default:
+			return nil, fmt.Errorf("%d: expected command, but got %v", tok.line, tok.text)
+		}
+		p.pos++
+
+		cmd := command{name: tok.text, line: tok.line}
+		if cmd.name == "repeat" || cmd.name == "while" {
+			for p.pos < len(p.tokens) && p.tokens[p.pos].kind == WORD_TOKEN {
+				cmd.args = append(cmd.args, p.tokens[p.pos].text)
+				p.pos++
+			}
+			if p.pos == len(p.tokens) || p.tokens[p.pos].kind != LBRACE_TOKEN {
+				return nil, fmt.Errorf("%d: %v expects { after its arguments", tok.line, cmd.name)
+			}
+			p.pos++
+			body, err := p.parseCommands()
+			if err != nil {
+				return nil, err
+			}
+			if p.pos == len(p.tokens) {
+				return nil, fmt.Errorf("%d: { of %v is not closed with }", tok.line, cmd.name)
+			}
+			p.pos++
+			cmd.body = body
+			commands = append(commands, cmd)
+			continue
+		}
+
+		for p.pos < len(p.tokens) && (p.tokens[p.pos].kind == WORD_TOKEN || p.tokens[p.pos].kind == STRING_TOKEN) {
+			cmd.args = append(cmd.args, p.tokens[p.pos].text)
+			p.pos++
+		}
+		if p.pos == len(p.tokens) || p.tokens[p.pos].kind != END_TOKEN {
+			return nil, fmt.Errorf("%d: %v is not ended with , ; or !", tok.line, cmd.name)
+		}
+		p.pos++
+		commands = append(commands, cmd)
+	}
+	return commands, nil
+}
@@ -1,10 +1,17 @@
-module github.com/ishwar00/VMTranslator
+module github.com/ishwar00/VMTranslator07
 
 go 1.18
 
 require (
-	github.com/fatih/color v1.13.0 // indirect
+	github.com/fatih/color v1.13.0
+	github.com/ishwar00/VMTranslator v0.0.0
+)
+
+require (
 	github.com/mattn/go-colorable v0.1.9 // indirect
 	github.com/mattn/go-isatty v0.0.14 // indirect
 	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c // indirect
 )
+
+// translator is shared with VMTranslator of project 08
+replace github.com/ishwar00/VMTranslator => "../../../08 Virtual Machine 2 (control)/VMTranslator/src"
@@ -1,19 +1,48 @@
 package main
 
 import (
+	"fmt"
+	"log"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/fatih/color"
+	"github.com/ishwar00/VMTranslator/translator"
 )
 
 const doc = `expected way to run VMTranslator is: ./VMTranslator <path to .vm file>`
 
+// translates a .vm file of project 07 into .asm file next to it, program has
+// no bootstrap, tests set up the stack, and ends in an infinite loop.
 func main() {
 	if len(os.Args) != 2 {
-		panic(color.RedString(doc))
+		log.Fatal(color.RedString(doc))
 	}
 
 	if err := virtualMachine(os.Args[1]); err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
 }
+
+func virtualMachine(vmFilePath string) error {
+	if filepath.Ext(vmFilePath) != ".vm" {
+		return fmt.Errorf(color.RedString("provided argument must be a file with .vm extension"))
+	}
+	file, err := os.Open(vmFilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	sources := []translator.Source{{Name: vmFilePath, Code: file}}
+	lines, err := translator.Translate(sources, translator.Options{HaltLoop: true})
+	if err != nil {
+		return err
+	}
+
+	// Prog.vm is translated into Prog.asm
+	directory, fileName := filepath.Split(vmFilePath)
+	asmFilePath := filepath.Join(directory, strings.Split(fileName, ".")[0]+".asm")
+	return os.WriteFile(asmFilePath, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
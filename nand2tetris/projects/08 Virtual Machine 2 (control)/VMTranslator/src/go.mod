@@ -2,8 +2,9 @@ module github.com/ishwar00/VMTranslator
 
 go 1.18
 
+require github.com/fatih/color v1.13.0
+
 require (
-	github.com/fatih/color v1.13.0 // indirect
 	github.com/mattn/go-colorable v0.1.9 // indirect
 	github.com/mattn/go-isatty v0.0.14 // indirect
 	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c // indirect
@@ -1,20 +1,45 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
-	"os"
 
 	"github.com/fatih/color"
 )
 
-const doc = `expected way to run VMTranslator is: ./VMTranslator <path to .vm file>`
+const doc = `expected way to run VMTranslator is: ./VMTranslator [flags] <path to .vm file or directory>
+
+ Translates a .vm file, or all .vm files of a directory, into a single .asm
+ file next to them. Bootstrap code calling Sys.init is written, unless
+ -bootstrap says otherwise, with auto only if program has Sys.vm, as tests
+ without Sys.vm, eg: BasicLoop, set up the stack themselves.
+
+ flags:
+`
+
+var bootstrap = flag.String("bootstrap", "on", "write bootstrap code: on, off or auto")
+
+var imports = flag.Bool("imports", false,
+	"declare functions called, but not defined by translated files, with .import,\nfor HackAssembler -c and hacklink")
+
+var halt = flag.Bool("halt", false, "end program with an infinite loop (END), as VMTranslator of project 07 does")
 
 func main() {
-	if len(os.Args) != 2 {
-		log.Fatal(color.RedString(doc))
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), color.RedString(doc))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		log.Fatal(color.RedString("expected a .vm file or directory"))
+	}
+	if *bootstrap != "on" && *bootstrap != "off" && *bootstrap != "auto" {
+		log.Fatal(color.RedString("-bootstrap must be on, off or auto, but got %v", *bootstrap))
 	}
 
-	if err := virtualMachine(os.Args[1]); err != nil {
+	if err := virtualMachine(flag.Arg(0)); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -1,8 +1,7 @@
-package main
+package translator
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -10,24 +9,21 @@ import (
 )
 
 type CodeWriter struct {
-	outputFile    *os.File
-	closer        func()
+	lines         []string // assembly written so far
 	segmentMap    map[string]string
 	labelId       int
 	currentVMFile string
+	functions     map[string]bool // functions defined so far
+	callees       []string        // functions called so far, in order of first call
 }
 
-func (cr *CodeWriter) Initialize(filePath string) error {
-	asmFile, closer, err := CreateOutputFile(filePath)
-	if err != nil {
-		return err
-	}
-
+func (cr *CodeWriter) Initialize() {
 	*cr = CodeWriter{
-		outputFile:    asmFile,
-		closer:        closer,
+		lines:         []string{},
 		labelId:       0,
 		currentVMFile: "null",
+		functions:     map[string]bool{},
+		callees:       []string{},
 		segmentMap: map[string]string{
 			"local":    "LCL",
 			"argument": "ARG",
@@ -36,7 +32,6 @@ func (cr *CodeWriter) Initialize(filePath string) error {
 			"temp":     "TEMP",
 		},
 	}
-	return nil
 }
 
 func (cr *CodeWriter) InjectBootstrapCode() {
@@ -92,28 +87,49 @@ func (cr *CodeWriter) InjectBootstrapCode() {
 		"@Sys.init",
 		"0;JMP",
 	}
+	cr.addCallee("Sys.init")
 	cr.Write(code)
 }
 
+func (cr *CodeWriter) addCallee(function string) {
+	for _, callee := range cr.callees {
+		if callee == function {
+			return
+		}
+	}
+	cr.callees = append(cr.callees, function)
+}
+
+// functions called by code written so far, but not defined by it, in order of first call
+func (cr *CodeWriter) UndefinedFunctions() []string {
+	undefined := []string{}
+	for _, callee := range cr.callees {
+		if !cr.functions[callee] {
+			undefined = append(undefined, callee)
+		}
+	}
+	return undefined
+}
+
 // implements command: label SOME_LABEL
-func (cr CodeWriter) WriteLabel(label, functionName string) error {
+func (cr *CodeWriter) WriteLabel(label, functionName string) {
 	code := []string{
 		fmt.Sprintf("(%v$%v)", functionName, label),
 	}
-	return cr.Write(code)
+	cr.Write(code)
 }
 
 // implements command: goto SOME_LABEL
-func (cr CodeWriter) WriteGoto(label, functionName string) error {
+func (cr *CodeWriter) WriteGoto(label, functionName string) {
 	code := []string{
 		fmt.Sprintf("@%v$%v", functionName, label),
 		"0;JMP",
 	}
-	return cr.Write(code)
+	cr.Write(code)
 }
 
 // implements command: if-goto SOME_LABEL
-func (cr CodeWriter) WriteIf(label, functionName string) error {
+func (cr *CodeWriter) WriteIf(label, functionName string) {
 	code := []string{
 		"@SP",
 		"M=M-1",
@@ -123,11 +139,12 @@ func (cr CodeWriter) WriteIf(label, functionName string) error {
 		"D;JNE",
 	}
 
-	return cr.Write(code)
+	cr.Write(code)
 }
 
 // implements command: function functionName Vargs
-func (cr CodeWriter) WriteFunction(functionName string, nVars int) error {
+func (cr *CodeWriter) WriteFunction(functionName string, nVars int) {
+	cr.functions[functionName] = true
 	code := []string{
 		fmt.Sprintf("(%v)", functionName),
 	}
@@ -143,11 +160,12 @@ func (cr CodeWriter) WriteFunction(functionName string, nVars int) error {
 	for i := 0; i < nVars; i++ {
 		code = append(code, pushCode...) // initializing local variables to zero
 	}
-	return cr.Write(code)
+	cr.Write(code)
 }
 
 // implements command: call functionName nArgs
-func (cr CodeWriter) WriteCall(calleeFunction, currentFunction string, nArgs int, callCount int) error {
+func (cr *CodeWriter) WriteCall(calleeFunction, currentFunction string, nArgs int, callCount int) {
+	cr.addCallee(calleeFunction)
 	//					|	    ... 		 |
 	//					|	    ... 		 |
 	// 		ARG -->		| 		arg0		 |		caller stack just before jumping to callee's code block.
@@ -213,11 +231,11 @@ func (cr CodeWriter) WriteCall(calleeFunction, currentFunction string, nArgs int
 		// injecting label (returnAddress)
 		fmt.Sprintf("(%v)", returnAddress),
 	)
-	return cr.Write(code)
+	cr.Write(code)
 }
 
 // implements command: return
-func (cr CodeWriter) WriteReturn() error {
+func (cr *CodeWriter) WriteReturn() {
 	//						 	    ARG  --> |		arg0	   |
 	//										 |		...		   |
 	//										 |		arg1	   |
@@ -306,7 +324,7 @@ func (cr CodeWriter) WriteReturn() error {
 		"A=M",
 		"0;JMP",
 	}
-	return cr.Write(code)
+	cr.Write(code)
 }
 
 // should be used to set to current compiling .vm file
@@ -317,6 +335,9 @@ func (cr *CodeWriter) SetFilePath(filePath string) {
 func (cr *CodeWriter) WritePushPop(command int, segment string, index int) error {
 	var code []string
 	pointer := []string{"THIS", "THAT"}
+	if segment == "pointer" && (index < 0 || index >= len(pointer)) {
+		return fmt.Errorf("index of segment pointer must be 0 or 1, but got %v", color.RedString(fmt.Sprint(index)))
+	}
 	fileName := filepath.Base(cr.currentVMFile)
 	fileName = strings.Split(fileName, ".")[0]
 	switch command {
@@ -446,23 +467,20 @@ func (cr *CodeWriter) WritePushPop(command int, segment string, index int) error
 		// this block is not supposed to be executed ever, if it did, it's an unknown segment error
 		return fmt.Errorf("unknown segment %v encountered ", color.RedString(segment))
 	}
-	return cr.Write(code)
+	cr.Write(code)
+	return nil
 }
 
-func (cr *CodeWriter) Write(code []string) error {
+// appends code to lines written, instructions are indented, labels and
+// comments are not
+func (cr *CodeWriter) Write(code []string) {
 	tab := "\t"
-	newLine := "\n"
 	for _, asm := range code {
 		if asm = strings.TrimSpace(asm); (asm[0] != '(') && (!strings.Contains(asm, "//")) {
 			asm = tab + asm
 		}
-
-		_, err := cr.outputFile.Write([]byte(asm + newLine))
-		if err != nil {
-			return fmt.Errorf(color.RedString(err.Error()))
-		}
+		cr.lines = append(cr.lines, asm)
 	}
-	return nil
 }
 
 func (cr *CodeWriter) WriteArithmetic(command string) error {
@@ -485,8 +503,11 @@ func (cr *CodeWriter) WriteArithmetic(command string) error {
 		}
 	case "eq", "gt", "lt":
 		code = cr.codeForGtLtEq(command)
+	default:
+		return fmt.Errorf("unknown arithmetic command %v encountered ", color.RedString(command))
 	}
-	return cr.Write(code)
+	cr.Write(code)
+	return nil
 }
 
 func (cr CodeWriter) codeForAddSubAndOr(command string) []string {
@@ -550,6 +571,7 @@ func (cr *CodeWriter) codeForGtLtEq(command string) []string {
 	return code
 }
 
-func (cr CodeWriter) Close() {
-	cr.closer()
+// returns lines of assembly written so far
+func (cr CodeWriter) Lines() []string {
+	return cr.lines
 }
@@ -1,14 +1,11 @@
-package main
+package translator
 
 import (
 	"bufio"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io"
 	"strconv"
 	"strings"
-
-	"github.com/fatih/color"
 )
 
 const (
@@ -36,23 +33,9 @@ type Parser struct {
 	nextInstr       int // next instruction
 }
 
-/// takes input file, reads through the program line by line and removes
+/// takes vm code, reads through the program line by line and removes
 /// comment and trims white spaces around the instruction, and puts in a slice.
-func (p *Parser) Initialize(filePath string) error {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	fileInfo, err := file.Stat()
-	if err != nil {
-		return err
-	}
-	if fileInfo.IsDir() || filepath.Ext(filePath) != ".vm" {
-		panic(fmt.Errorf(color.RedString("provided argument must be a file with .vm extension")))
-	}
-
+func (p *Parser) Initialize(code io.Reader) error {
 	*p = Parser{
 		instrInfo:       []InstructionInfo{},
 		nextInstr:       -1,
@@ -60,7 +43,7 @@ func (p *Parser) Initialize(filePath string) error {
 		CallCount:       0,
 	}
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(code)
 	for lineNumber := 0; scanner.Scan(); lineNumber++ {
 		instruction := scanner.Text()
 		if at := strings.Index(instruction, "//"); at != -1 {
@@ -76,7 +59,7 @@ func (p *Parser) Initialize(filePath string) error {
 			p.instrInfo = append(p.instrInfo, instrInfo)
 		}
 	}
-	return nil
+	return scanner.Err()
 }
 
 func (p Parser) HasMoreLines() bool {
@@ -90,10 +73,13 @@ func (p *Parser) Advance() {
 	if p.HasMoreLines() {
 		p.nextInstr += 1
 		instruction := p.GetInstrInfo().Instruction
+		fields := strings.Fields(instruction)
 		switch p.commandType(instruction) {
 		case C_FUNCTION:
 			p.CallCount = 0
-			p.CurrentFunction = strings.Fields(instruction)[1] // function name
+			if len(fields) > 1 {
+				p.CurrentFunction = fields[1] // function name
+			}
 		case C_CALL:
 			p.CallCount += 1
 		}
@@ -0,0 +1,144 @@
+// Package translator translates VM code of nand2tetris into Hack assembly,
+// it is shared by VMTranslator of project 07, which needs only push, pop and
+// arithmetic commands, and the full one of project 08.
+package translator
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Source is a .vm file to translate.
+type Source struct {
+	Name string    // path of file, its base name names statics, eg: Foo.vm has Foo.0, Foo.1 ...
+	Code io.Reader // vm code of file
+}
+
+type Options struct {
+	// sets up stack and jumps to Sys.init before code of files, programs of
+	// project 08 with Sys.vm need it, tests of single files set up the stack
+	// themselves.
+	Bootstrap bool
+
+	// ends code of files with an infinite loop (END), so a program without
+	// Sys.init does not run into memory after it, as project 07 does.
+	HaltLoop bool
+
+	// declares functions called, but not defined by files, with .import, so
+	// code of a single file can be assembled with HackAssembler -c and linked
+	// with other files by hacklink.
+	Imports bool
+}
+
+// number of fields of every command, including command itself
+var commandFields = map[int]int{
+	C_ARITHMETIC: 1,
+	C_PUSH:       3,
+	C_POP:        3,
+	C_LABEL:      2,
+	C_GOTO:       2,
+	C_IF:         2,
+	C_FUNCTION:   3,
+	C_RETURN:     1,
+	C_CALL:       3,
+}
+
+// Translate translates files in order into lines of Hack assembly.
+func Translate(files []Source, opts Options) ([]string, error) {
+	var parser Parser
+	var codeWriter CodeWriter
+
+	codeWriter.Initialize()
+	if opts.Bootstrap {
+		codeWriter.InjectBootstrapCode()
+	}
+
+	for _, file := range files {
+		if err := parser.Initialize(file.Code); err != nil {
+			return nil, fmt.Errorf("%v: %v", file.Name, err)
+		}
+		codeWriter.SetFilePath(file.Name)
+		if err := translateFile(&parser, &codeWriter); err != nil {
+			return nil, fmt.Errorf("%v: %v", file.Name, err)
+		}
+	}
+
+	if opts.HaltLoop {
+		codeWriter.Write([]string{ // an infinite loop
+			"(END)",
+			"@END",
+			"0;JMP",
+		})
+	}
+	lines := codeWriter.Lines()
+	if opts.Imports {
+		imports := []string{}
+		for _, function := range codeWriter.UndefinedFunctions() {
+			imports = append(imports, ".import "+function)
+		}
+		lines = append(imports, lines...)
+	}
+	return lines, nil
+}
+
+func translateFile(parser *Parser, codeWriter *CodeWriter) error {
+	comment := "// "
+	for parser.HasMoreLines() {
+		parser.Advance()
+		instrInfo := parser.GetInstrInfo()
+		fields := strings.Fields(instrInfo.Instruction)
+		if expected, ok := commandFields[instrInfo.Type]; !ok {
+			return PrepError(instrInfo, fmt.Errorf(color.RedString("unrecognized command")))
+		} else if len(fields) != expected {
+			errMsg := fmt.Errorf("expected %v fields, but got %v", expected, color.RedString(fmt.Sprint(len(fields))))
+			return PrepError(instrInfo, errMsg)
+		}
+
+		codeWriter.Write([]string{comment + parser.GetInstrInfo().Instruction})
+		switch commandType := parser.CommandType(); commandType {
+		case C_PUSH, C_POP:
+			segment := parser.Arg1()
+			index, err := parser.Arg2()
+			if err != nil { // failed to parse index
+				return err
+			}
+			err = codeWriter.WritePushPop(commandType, segment, index)
+			if err != nil { // unknown segment or index
+				return PrepError(parser.GetInstrInfo(), err)
+			}
+		case C_ARITHMETIC:
+			command := parser.Arg1()
+			err := codeWriter.WriteArithmetic(command)
+			if err != nil {
+				return PrepError(parser.GetInstrInfo(), err)
+			}
+
+		case C_LABEL:
+			codeWriter.WriteLabel(fields[1], parser.CurrentFunction)
+		case C_GOTO:
+			codeWriter.WriteGoto(fields[1], parser.CurrentFunction)
+		case C_IF:
+			codeWriter.WriteIf(fields[1], parser.CurrentFunction)
+		case C_FUNCTION:
+			nVars, err := strconv.ParseInt(fields[2], 10, 32)
+			if err != nil {
+				return PrepError(parser.GetInstrInfo(), err)
+			}
+			codeWriter.WriteFunction(parser.CurrentFunction, int(nVars))
+
+		case C_CALL:
+			nArgs, err := strconv.ParseInt(fields[2], 10, 32)
+			if err != nil {
+				return PrepError(parser.GetInstrInfo(), err)
+			}
+			codeWriter.WriteCall(fields[1], parser.CurrentFunction, int(nArgs), parser.CallCount)
+		case C_RETURN:
+			codeWriter.WriteReturn()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package translator
+
+import (
+	"strings"
+	"testing"
+)
+
+func source(name string, lines ...string) Source {
+	return Source{Name: name, Code: strings.NewReader(strings.Join(lines, "\n"))}
+}
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		opts        Options
+		first, last string
+	}{
+		{Options{}, "// push constant 7", "\tM=M+1"},
+		{Options{HaltLoop: true}, "// push constant 7", "\t0;JMP"},
+		{Options{Bootstrap: true}, "\t@261", "\tM=M+1"},
+		{Options{Bootstrap: true, HaltLoop: true}, "\t@261", "\t0;JMP"},
+	}
+
+	for i, tt := range tests {
+		lines, err := Translate([]Source{source("Foo.vm", "push constant 7")}, tt.opts)
+		if err != nil {
+			t.Fatalf("tests[%d]: %v", i, err)
+		}
+		if first, last := lines[0], lines[len(lines)-1]; first != tt.first || last != tt.last {
+			t.Fatalf("tests[%d]: expected=%q...%q, but got=%q...%q", i, tt.first, tt.last, first, last)
+		}
+		hasEnd := strings.Contains(strings.Join(lines, "\n"), "(END)")
+		if hasEnd != tt.opts.HaltLoop {
+			t.Fatalf("tests[%d]: expected (END) only with HaltLoop, but got=%v", i, hasEnd)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	files := []Source{
+		source("dir/Foo.vm", "function Foo.main 0", "push static 1", "call Bar.f 1", "return"),
+		source("dir/Bar.vm", "function Bar.f 0", "label LOOP", "pop static 1", "goto LOOP"),
+	}
+	lines, err := Translate(files, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := strings.Join(lines, "\n")
+	for _, expected := range []string{"(Foo.main)", "@Foo.1", "(Foo.main$ret.1)", "(Bar.f)", "(Bar.f$LOOP)", "@Bar.1"} {
+		if !strings.Contains(code, expected) {
+			t.Fatalf("expected=%v in code, but got=\n%v", expected, code)
+		}
+	}
+}
+
+func TestImports(t *testing.T) {
+	tests := []struct {
+		opts     Options
+		expected []string
+	}{
+		{Options{}, []string{}},
+		{Options{Imports: true}, []string{".import Bar.f", ".import Math.abs"}},
+		{Options{Imports: true, Bootstrap: true}, []string{".import Sys.init", ".import Bar.f", ".import Math.abs"}},
+	}
+
+	for i, tt := range tests {
+		files := []Source{
+			source("dir/Foo.vm", "function Foo.main 0", "call Bar.f 0", "call Foo.g 0", "call Bar.f 0", "return"),
+			source("dir/Baz.vm", "function Foo.g 0", "call Math.abs 1", "return"),
+		}
+		lines, err := Translate(files, tt.opts)
+		if err != nil {
+			t.Fatalf("tests[%d]: %v", i, err)
+		}
+		imports := []string{}
+		for _, line := range lines {
+			if strings.HasPrefix(line, ".import") {
+				imports = append(imports, line)
+			}
+		}
+		if strings.Join(imports, "\n") != strings.Join(tt.expected, "\n") || len(imports) > 0 && lines[0] != imports[0] {
+			t.Fatalf("tests[%d]: expected=%q at start, but got=%q", i, tt.expected, imports)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"push constant", "expected 3 fields"},
+		{"push nowhere 1", "unknown segment"},
+		{"push pointer 2", "index of segment pointer must be 0 or 1"},
+		{"pop local x", "invalid syntax"},
+		{"jump far", "unrecognized command"},
+		{"function Foo.bar x", "invalid syntax"},
+	}
+
+	for i, tt := range tests {
+		_, err := Translate([]Source{source("Foo.vm", "push constant 1", tt.line)}, Options{})
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Fatalf("tests[%d]: %v: expected=%v, but got=%v", i, tt.line, tt.expected, err)
+		}
+		if !strings.HasPrefix(err.Error(), "Foo.vm: ") {
+			t.Fatalf("tests[%d]: expected error to name file, but got=%v", i, err)
+		}
+	}
+}
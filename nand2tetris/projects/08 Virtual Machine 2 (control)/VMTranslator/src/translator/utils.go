@@ -0,0 +1,12 @@
+package translator
+
+import (
+	"fmt"
+)
+
+/// prepares error to report
+func PrepError(instrInfo InstructionInfo, err error) error {
+	return fmt.Errorf(`
+Error on line:%v | %v
+  	           ^^^^^^^ %v`, instrInfo.OnLine, instrInfo.Instruction, err.Error())
+}
@@ -1,19 +1,11 @@
 package main
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
-/// prepares error to report
-func PrepError(instrInfo InstructionInfo, err error) error {
-	return fmt.Errorf(`
-Error on line:%v | %v
-  	           ^^^^^^^ %v`, instrInfo.OnLine, instrInfo.Instruction, err.Error())
-}
-
 // creates file with the same name and directory as filePath with file extension asm,
 // eg: if filePath is example/path/Prog.vm, then it creates a file example/path/Prog.asm
 // it will overwrite if already such file exists.
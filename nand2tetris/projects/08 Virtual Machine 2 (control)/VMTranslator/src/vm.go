@@ -5,10 +5,10 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 
 	"github.com/fatih/color"
+	"github.com/ishwar00/VMTranslator/translator"
 )
 
 func virtualMachine(vmSource string) error {
@@ -17,82 +17,35 @@ func virtualMachine(vmSource string) error {
 		return err
 	}
 
-	var parser Parser
-	var codeWriter CodeWriter
-
-	codeWriter.Initialize(vmSource)
-	codeWriter.InjectBootstrapCode()
-	defer codeWriter.closer()
-
+	sources := []translator.Source{}
+	hasSys := false
 	for _, vmFilePath := range vmSourceFiles {
-		parser.Initialize(vmFilePath)
-		codeWriter.SetFilePath(vmFilePath)
-		comment := "// "
-		for parser.HasMoreLines() {
-			parser.Advance()
-			codeWriter.Write([]string{comment + parser.GetInstrInfo().Instruction})
-			fields := strings.Fields(parser.GetInstrInfo().Instruction)
-			switch commandType := parser.CommandType(); commandType {
-			case C_PUSH, C_POP:
-				segment := parser.Arg1()
-				index, err := parser.Arg2()
-				if err != nil { // failed to parse index
-					return err
-				}
-				err = codeWriter.WritePushPop(commandType, segment, index)
-				if err != nil { // failed while either writing into file or parsing
-					return PrepError(parser.GetInstrInfo(), err)
-				}
-			case C_ARITHMETIC:
-				command := parser.Arg1()
-				err := codeWriter.WriteArithmetic(command)
-				if err != nil {
-					return PrepError(parser.GetInstrInfo(), err)
-				}
+		file, err := os.Open(vmFilePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		sources = append(sources, translator.Source{Name: vmFilePath, Code: file})
+		hasSys = hasSys || filepath.Base(vmFilePath) == "Sys.vm"
+	}
 
-			case C_LABEL:
-				err := codeWriter.WriteLabel(fields[1], parser.CurrentFunction)
-				if err != nil {
-					return err
-				}
-			case C_GOTO:
-				err := codeWriter.WriteGoto(fields[1], parser.CurrentFunction)
-				if err != nil {
-					return err
-				}
-			case C_IF:
-				err := codeWriter.WriteIf(fields[1], parser.CurrentFunction)
-				if err != nil {
-					return err
-				}
-			case C_FUNCTION:
-				nVars, err := strconv.ParseInt(fields[2], 10, 32)
-				if err != nil {
-					return PrepError(parser.GetInstrInfo(), err)
-				}
-				err = codeWriter.WriteFunction(parser.CurrentFunction, int(nVars))
-				if err != nil {
-					return err
-				}
+	opts := translator.Options{
+		Bootstrap: *bootstrap == "on" || *bootstrap == "auto" && hasSys,
+		HaltLoop:  *halt,
+		Imports:   *imports,
+	}
+	lines, err := translator.Translate(sources, opts)
+	if err != nil {
+		return err
+	}
 
-			case C_CALL:
-				nArgs, err := strconv.ParseInt(fields[2], 10, 32)
-				if err != nil {
-					return PrepError(parser.GetInstrInfo(), err)
-				}
-				err = codeWriter.WriteCall(fields[1], parser.CurrentFunction, int(nArgs), parser.CallCount)
-				if err != nil {
-					return err
-				}
-			case C_RETURN:
-				if err := codeWriter.WriteReturn(); err != nil {
-					return err
-				}
-			default:
-				errMsg := fmt.Errorf(color.RedString("unrecognized command"))
-				return PrepError(parser.GetInstrInfo(), errMsg)
-			}
-		}
+	asmFile, closer, err := CreateOutputFile(vmSource)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	if _, err := asmFile.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		return fmt.Errorf(color.RedString(err.Error()))
 	}
 	return nil
 }
@@ -122,8 +75,10 @@ func vmFiles(vmSourcePath string) ([]string, error) {
 				vmFiles = append(vmFiles, source)
 			}
 		}
-	} else {
+	} else if filepath.Ext(vmSourcePath) == ".vm" {
 		vmFiles = append(vmFiles, vmSourcePath)
+	} else {
+		return nil, fmt.Errorf(color.RedString("provided argument must be a file with .vm extension or a directory"))
 	}
 	return vmFiles, nil
 }
@@ -0,0 +1,35 @@
+#!/bin/sh
+# Translates test programs of projects 07 and 08 with VMTranslator of each
+# project and runs their .tst scripts with hacktest of project 06, which
+# compares output with .cmp files. *VME.tst scripts are for VM emulator and
+# are skipped. Programs are translated in a copy, committed files stay as
+# they are.
+#
+# eg: sh test_programs.sh
+set -e
+
+projects="$(cd "$(dirname "$0")/../.." && pwd)"
+work="$(mktemp -d)"
+trap 'rm -rf "$work"' EXIT
+
+(cd "$projects/06 Assembler/HackAssembler/src" && go build -o "$work/hacktest" ./cmd/hacktest)
+(cd "$projects/07 Virtual Machine 1 (processing)/VMTranslator/src" && go build -o "$work/vm07" .)
+(cd "$projects/08 Virtual Machine 2 (control)/VMTranslator/src" && go build -o "$work/vm08" .)
+
+mkdir "$work/07" "$work/08"
+cp -R "$projects/07 Virtual Machine 1 (processing)/MemoryAccess" \
+	"$projects/07 Virtual Machine 1 (processing)/StackArithmetic" "$work/07"
+cp -R "$projects/08 Virtual Machine 2 (control)/FunctionCalls" \
+	"$projects/08 Virtual Machine 2 (control)/ProgramFlow" "$work/08"
+
+# project 07 translates a single file, named after its directory
+for program in "$work"/07/*/*/; do
+	"$work/vm07" "$program$(basename "$program").vm"
+done
+# project 08 translates all files of directory into one program, tests
+# without Sys.vm set up the stack themselves
+for program in "$work"/08/*/*/; do
+	"$work/vm08" -bootstrap auto "$program"
+done
+
+find "$work/07" "$work/08" -name '*.tst' ! -name '*VME.tst' -exec "$work/hacktest" {} +